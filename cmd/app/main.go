@@ -62,6 +62,7 @@ func main() {
 	fmt.Println("🚀  Initializing Server...")
 	s, err := server.New(
 		server.WithAppEnv(env),
+		server.WithContext(ctx),
 		server.WithDatabase(database),
 		server.WithPublicFS(publicfs.FS),
 		server.WithWSHub(wshub.New()),
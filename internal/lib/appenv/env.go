@@ -14,6 +14,20 @@ type Env struct {
 	AppPort            int           `env:"APP_PORT;optional;min=1;max=65535"`
 	DatabaseURI        string        `env:"DATABASE_URI;optional"`
 	AppShutdownTimeout time.Duration `env:"APP_SHUTDOWN_TIMEOUT;optional;min=1s"`
+	ProcfsRoot         string        `env:"PROCFS_ROOT;optional"`
+	SysfsRoot          string        `env:"SYSFS_ROOT;optional"`
+	GPUPrimary         string        `env:"GPU_PRIMARY;optional"`
+	GPUBackend         string        `env:"GPU_BACKEND;optional;oneof=auto,amdgpu,nvidia,intel"`
+	DiskIncludeAll     bool          `env:"DISK_INCLUDE_ALL;optional"`
+	NetIncludeAll      bool          `env:"NET_INCLUDE_ALL;optional"`
+	StorageSmartctl    bool          `env:"STORAGE_SMARTCTL;optional"`
+	ProcessSampling    bool          `env:"PROCESS_SAMPLING;optional"`
+	ProcessTopN        int           `env:"PROCESS_TOP_N;optional;min=1"`
+	FSIncludeTypes     string        `env:"FS_INCLUDE_TYPES;optional"`
+	FSExcludeTypes     string        `env:"FS_EXCLUDE_TYPES;optional"`
+	FSIncludeMounts    string        `env:"FS_INCLUDE_MOUNTS;optional"`
+	FSExcludeMounts    string        `env:"FS_EXCLUDE_MOUNTS;optional"`
+	CgroupPaths        string        `env:"CGROUP_PATHS;optional"`
 }
 
 func New() *Env {
@@ -22,6 +36,9 @@ func New() *Env {
 		AppPort:            9070,
 		DatabaseURI:        "~/.config/sensorpanel.db.sqlite3",
 		AppShutdownTimeout: 1 * time.Second,
+		ProcfsRoot:         "/proc",
+		SysfsRoot:          "/sys",
+		GPUBackend:         "auto",
 	}
 	err := simpleenv.Load(env)
 	if err != nil {
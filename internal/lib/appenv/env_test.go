@@ -42,6 +42,23 @@ func TestLoadSuccess(t *testing.T) {
 	}
 }
 
+func TestLoadSensorRoots(t *testing.T) {
+	t.Setenv("PROCFS_ROOT", "/host/proc")
+	t.Setenv("SYSFS_ROOT", "/host/sys")
+
+	env, err := loadForTest()
+	if err != nil {
+		t.Fatalf("loadForTest returned error: %v", err)
+	}
+
+	if env.ProcfsRoot != "/host/proc" {
+		t.Fatalf("expected ProcfsRoot /host/proc, got %q", env.ProcfsRoot)
+	}
+	if env.SysfsRoot != "/host/sys" {
+		t.Fatalf("expected SysfsRoot /host/sys, got %q", env.SysfsRoot)
+	}
+}
+
 func TestLoadMissingOptionalEnv(t *testing.T) {
 	os.Unsetenv("APP_ENV")
 	os.Unsetenv("APP_PORT")
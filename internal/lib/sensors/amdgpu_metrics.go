@@ -0,0 +1,283 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var errGPUMetricsTooShort = errors.New("gpu_metrics table too short")
+
+// gpuMetricsUnavailable is what the SMU reports for fields an ASIC does not
+// support.
+const gpuMetricsUnavailable = 0xffff
+
+// gpuMetricsThrottlers names the ASIC-independent throttler bits of
+// indep_throttle_status (SMU_THROTTLER_*_BIT in kgd_pp_interface.h).
+var gpuMetricsThrottlers = []struct {
+	bit  uint
+	name string
+}{
+	{0, "ppt0"}, {1, "ppt1"}, {2, "ppt2"}, {3, "ppt3"},
+	{4, "spl"}, {5, "fppt"}, {6, "sppt"}, {7, "sppt_apu"},
+	{16, "tdc_gfx"}, {17, "tdc_soc"}, {18, "tdc_mem"}, {19, "tdc_vdd"},
+	{20, "tdc_cvip"}, {21, "edc_cpu"}, {22, "edc_gfx"}, {23, "apcc"},
+	{32, "temp_gpu"}, {33, "temp_core"}, {34, "temp_mem"}, {35, "temp_edge"},
+	{36, "temp_hotspot"}, {37, "temp_soc"}, {38, "temp_vr_gfx"}, {39, "temp_vr_soc"},
+	{40, "temp_vr_mem0"}, {41, "temp_vr_mem1"}, {42, "temp_liquid0"}, {43, "temp_liquid1"},
+	{44, "vr_hot0"}, {45, "vr_hot1"},
+	{56, "prochot_cpu"}, {57, "prochot_gfx"},
+	{62, "ppm"}, {63, "fit"},
+}
+
+type AMDGPUMetricsSnapshot struct {
+	GPUs []AMDGPUMetrics
+}
+
+// AMDGPUMetrics is the decoded gpu_metrics table of one amdgpu card.
+// Fields the table revision or ASIC does not provide are left at 0;
+// Readings flags the ones it does.
+type AMDGPUMetrics struct {
+	PCIAddr         string
+	Card            string
+	FormatRevision  int
+	ContentRevision int
+	Readings        GPUReadings
+
+	EdgeC    float64
+	HotspotC float64
+	MemC     float64
+	SocC     float64
+
+	GfxActivityPct float64
+	SocketPowerW   float64
+
+	GfxClockMHz float64
+	SocClockMHz float64
+	MemClockMHz float64
+
+	FanRPM float64
+	FanPWM float64
+
+	// ThrottleStatus is the ASIC-specific throttler bitmask. Throttlers
+	// lists the ASIC-independent reasons when the table revision has them.
+	ThrottleStatus uint32
+	Throttlers     []string
+}
+
+// Throttling reports whether any throttler was active.
+func (m AMDGPUMetrics) Throttling() bool {
+	return m.ThrottleStatus != 0 || len(m.Throttlers) > 0
+}
+
+type AMDGPUMetricsSampler struct {
+	lifecycle
+
+	mu      sync.RWMutex
+	cards   []drmCard
+	metrics map[string]AMDGPUMetrics
+}
+
+func NewAMDGPUMetricsSampler(interval time.Duration, root Root) *AMDGPUMetricsSampler {
+	cards := detectGPUMetricsCards(root)
+	s := &AMDGPUMetricsSampler{cards: cards, metrics: make(map[string]AMDGPUMetrics)}
+	if len(cards) > 0 {
+		s.onStart(interval, s.run)
+	}
+
+	return s
+}
+
+func (s *AMDGPUMetricsSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, card := range s.cards {
+			raw, err := os.ReadFile(gpuMetricsPath(card))
+			if err != nil {
+				continue
+			}
+			metrics, err := parseGPUMetrics(raw)
+			if err != nil {
+				continue
+			}
+			metrics.PCIAddr = card.PCIAddr
+			metrics.Card = card.Name
+
+			s.mu.Lock()
+			s.metrics[card.PCIAddr] = metrics
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *AMDGPUMetricsSampler) Snapshot() AMDGPUMetricsSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var snapshot AMDGPUMetricsSnapshot
+	for _, card := range s.cards {
+		metrics, ok := s.metrics[card.PCIAddr]
+		if !ok {
+			continue
+		}
+		metrics.Throttlers = append([]string(nil), metrics.Throttlers...)
+		snapshot.GPUs = append(snapshot.GPUs, metrics)
+	}
+
+	return snapshot
+}
+
+func detectGPUMetricsCards(root Root) []drmCard {
+	var cards []drmCard
+	for _, card := range detectDRMCards(root) {
+		if _, err := os.Stat(gpuMetricsPath(card)); err != nil {
+			continue
+		}
+		cards = append(cards, card)
+	}
+
+	return cards
+}
+
+func gpuMetricsPath(card drmCard) string {
+	return filepath.Join(card.DeviceDir, "gpu_metrics")
+}
+
+// parseGPUMetrics decodes a gpu_metrics table. Format revision 1 is the
+// dGPU layout (content revisions 0-3), format revision 2 the APU layout
+// (content revisions 0-4). Offsets follow the naturally aligned structs in
+// the kernel's kgd_pp_interface.h.
+func parseGPUMetrics(raw []byte) (AMDGPUMetrics, error) {
+	if len(raw) < 4 {
+		return AMDGPUMetrics{}, errGPUMetricsTooShort
+	}
+
+	t := gpuMetricsTable(raw)
+	m := AMDGPUMetrics{FormatRevision: int(raw[2]), ContentRevision: int(raw[3])}
+	if size := int(t.u16(0)); size > len(raw) {
+		return AMDGPUMetrics{}, errGPUMetricsTooShort
+	}
+
+	switch {
+	case m.FormatRevision == 1 && m.ContentRevision <= 3:
+		return parseGPUMetricsV1(t, m)
+	case m.FormatRevision == 2 && m.ContentRevision <= 4:
+		return parseGPUMetricsV2(t, m)
+	default:
+		return AMDGPUMetrics{}, fmt.Errorf("unsupported gpu_metrics revision %d.%d", m.FormatRevision, m.ContentRevision)
+	}
+}
+
+func parseGPUMetricsV1(t gpuMetricsTable, m AMDGPUMetrics) (AMDGPUMetrics, error) {
+	if len(t) < 76 {
+		return AMDGPUMetrics{}, errGPUMetricsTooShort
+	}
+
+	// v1.0 leads with system_clock_counter; v1.1+ moved it after the
+	// power fields. Everything from the average clocks on lines up again.
+	temps := 4
+	if m.ContentRevision == 0 {
+		temps = 16
+	}
+
+	m.EdgeC = m.Readings.record(GPUEdgeTemp)(t.field(temps, 1))
+	m.HotspotC = m.Readings.record(GPUHotspotTemp)(t.field(temps+2, 1))
+	m.MemC = m.Readings.record(GPUMemTemp)(t.field(temps+4, 1))
+	m.GfxActivityPct = m.Readings.record(GPUUtil)(t.field(temps+12, 1))
+	m.SocketPowerW = m.Readings.record(GPUPower)(t.field(temps+18, 1))
+
+	m.GfxClockMHz = m.Readings.record(GPUGraphicsClock)(t.field(54, 1))
+	m.SocClockMHz, _ = t.field(56, 1)
+	m.MemClockMHz = m.Readings.record(GPUMemClock)(t.field(58, 1))
+	m.ThrottleStatus = t.u32(68)
+	m.FanRPM = m.Readings.record(GPUFan)(t.field(72, 1))
+
+	if m.ContentRevision >= 3 && len(t) >= 120 {
+		m.Throttlers = decodeThrottlers(t.u64(112))
+	}
+
+	return m, nil
+}
+
+func parseGPUMetricsV2(t gpuMetricsTable, m AMDGPUMetrics) (AMDGPUMetrics, error) {
+	// v2.0 leads with system_clock_counter; v2.1+ moved it after the
+	// activity fields, shifting everything before it by 12 bytes and
+	// everything after it by 4.
+	temps, power, clocks := 4, 40, 76
+	if m.ContentRevision == 0 {
+		temps, power, clocks = 16, 44, 80
+	}
+	if len(t) < clocks+38 {
+		return AMDGPUMetrics{}, errGPUMetricsTooShort
+	}
+
+	// APU temperatures are centi-°C, power mW and activity centi-%.
+	m.EdgeC = m.Readings.record(GPUEdgeTemp)(t.field(temps, 100))
+	m.SocC, _ = t.field(temps+2, 100)
+	m.GfxActivityPct = m.Readings.record(GPUUtil)(t.field(temps+24, 100))
+	m.SocketPowerW = m.Readings.record(GPUPower)(t.field(power, 1000))
+
+	m.GfxClockMHz = m.Readings.record(GPUGraphicsClock)(t.field(clocks, 1))
+	m.SocClockMHz, _ = t.field(clocks+2, 1)
+	m.MemClockMHz = m.Readings.record(GPUMemClock)(t.field(clocks+4, 1))
+	m.ThrottleStatus = t.u32(clocks + 32)
+	m.FanPWM, _ = t.field(clocks+36, 1)
+
+	if m.ContentRevision >= 2 && len(t) >= 128 {
+		m.Throttlers = decodeThrottlers(t.u64(120))
+	}
+
+	return m, nil
+}
+
+func decodeThrottlers(status uint64) []string {
+	var names []string
+	for _, throttler := range gpuMetricsThrottlers {
+		if status&(1<<throttler.bit) != 0 {
+			names = append(names, throttler.name)
+		}
+	}
+
+	return names
+}
+
+// gpuMetricsTable reads little-endian fields out of a raw gpu_metrics blob.
+type gpuMetricsTable []byte
+
+func (t gpuMetricsTable) u16(offset int) uint16 {
+	return binary.LittleEndian.Uint16(t[offset:])
+}
+
+func (t gpuMetricsTable) u32(offset int) uint32 {
+	return binary.LittleEndian.Uint32(t[offset:])
+}
+
+func (t gpuMetricsTable) u64(offset int) uint64 {
+	return binary.LittleEndian.Uint64(t[offset:])
+}
+
+// field reads a uint16 field scaled down by div. It reports false, with a
+// value of 0, for the SMU's "unsupported" marker.
+func (t gpuMetricsTable) field(offset int, div float64) (float64, bool) {
+	v := t.u16(offset)
+	if v == gpuMetricsUnavailable {
+		return 0, false
+	}
+
+	return float64(v) / div, true
+}
@@ -0,0 +1,138 @@
+package sensors
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func gpuMetricsFixture(size int, format byte, content byte) []byte {
+	raw := make([]byte, size)
+	binary.LittleEndian.PutUint16(raw[0:], uint16(size))
+	raw[2] = format
+	raw[3] = content
+	return raw
+}
+
+func putU16(raw []byte, offset int, v uint16) {
+	binary.LittleEndian.PutUint16(raw[offset:], v)
+}
+
+func TestParseGPUMetricsV1_3(t *testing.T) {
+	raw := gpuMetricsFixture(128, 1, 3)
+	putU16(raw, 4, 62)     // temperature_edge
+	putU16(raw, 6, 81)     // temperature_hotspot
+	putU16(raw, 8, 0xffff) // temperature_mem unsupported
+	putU16(raw, 16, 99)    // average_gfx_activity
+	putU16(raw, 22, 263)   // average_socket_power
+	putU16(raw, 54, 2604)  // current_gfxclk
+	putU16(raw, 56, 1200)  // current_socclk
+	putU16(raw, 58, 1249)  // current_uclk
+	binary.LittleEndian.PutUint32(raw[68:], 0x1)
+	putU16(raw, 72, 1630) // current_fan_speed
+	binary.LittleEndian.PutUint64(raw[112:], 1<<0|1<<36)
+
+	m, err := parseGPUMetrics(raw)
+	if err != nil {
+		t.Fatalf("parseGPUMetrics error: %v", err)
+	}
+
+	if m.FormatRevision != 1 || m.ContentRevision != 3 {
+		t.Fatalf("revision got %d.%d", m.FormatRevision, m.ContentRevision)
+	}
+	if m.EdgeC != 62 || m.HotspotC != 81 || m.MemC != 0 {
+		t.Fatalf("temps got edge=%v hotspot=%v mem=%v", m.EdgeC, m.HotspotC, m.MemC)
+	}
+	if m.Readings.Has(GPUMemTemp) || !m.Readings.Has(GPUEdgeTemp|GPUHotspotTemp|GPUFan) {
+		t.Fatalf("readings flags got %b", m.Readings)
+	}
+	if m.GfxActivityPct != 99 || m.SocketPowerW != 263 || m.FanRPM != 1630 {
+		t.Fatalf("activity/power/fan got %+v", m)
+	}
+	if m.GfxClockMHz != 2604 || m.SocClockMHz != 1200 || m.MemClockMHz != 1249 {
+		t.Fatalf("clocks got %+v", m)
+	}
+	if !m.Throttling() || !reflect.DeepEqual(m.Throttlers, []string{"ppt0", "temp_hotspot"}) {
+		t.Fatalf("throttlers got %v", m.Throttlers)
+	}
+}
+
+func TestParseGPUMetricsV1_0(t *testing.T) {
+	raw := gpuMetricsFixture(76, 1, 0)
+	putU16(raw, 16, 55)  // temperature_edge
+	putU16(raw, 34, 120) // average_socket_power
+	putU16(raw, 54, 1900)
+
+	m, err := parseGPUMetrics(raw)
+	if err != nil {
+		t.Fatalf("parseGPUMetrics error: %v", err)
+	}
+	if m.EdgeC != 55 || m.SocketPowerW != 120 || m.GfxClockMHz != 1900 || m.Throttling() {
+		t.Fatalf("v1.0 got %+v", m)
+	}
+}
+
+func TestParseGPUMetricsV2_1(t *testing.T) {
+	raw := gpuMetricsFixture(120, 2, 1)
+	putU16(raw, 4, 4650)   // temperature_gfx, centi-°C
+	putU16(raw, 6, 4425)   // temperature_soc
+	putU16(raw, 28, 5150)  // average_gfx_activity, centi-%
+	putU16(raw, 40, 18500) // average_socket_power, mW
+	putU16(raw, 76, 1600)  // current_gfxclk
+	putU16(raw, 80, 3200)  // current_uclk
+	binary.LittleEndian.PutUint32(raw[108:], 0x40)
+
+	m, err := parseGPUMetrics(raw)
+	if err != nil {
+		t.Fatalf("parseGPUMetrics error: %v", err)
+	}
+	if m.EdgeC != 46.5 || m.SocC != 44.25 || m.GfxActivityPct != 51.5 || m.SocketPowerW != 18.5 {
+		t.Fatalf("v2.1 readings got %+v", m)
+	}
+	if m.GfxClockMHz != 1600 || m.MemClockMHz != 3200 {
+		t.Fatalf("v2.1 clocks got %+v", m)
+	}
+	if m.ThrottleStatus != 0x40 || !m.Throttling() || m.Throttlers != nil {
+		t.Fatalf("v2.1 throttle got status=%#x throttlers=%v", m.ThrottleStatus, m.Throttlers)
+	}
+}
+
+func TestParseGPUMetricsV2_2IndependentThrottlers(t *testing.T) {
+	raw := gpuMetricsFixture(128, 2, 2)
+	binary.LittleEndian.PutUint64(raw[120:], 1<<7|1<<57)
+
+	m, err := parseGPUMetrics(raw)
+	if err != nil {
+		t.Fatalf("parseGPUMetrics error: %v", err)
+	}
+	if !reflect.DeepEqual(m.Throttlers, []string{"sppt_apu", "prochot_gfx"}) {
+		t.Fatalf("throttlers got %v", m.Throttlers)
+	}
+}
+
+func TestParseGPUMetricsRejectsUnknownAndShortTables(t *testing.T) {
+	if _, err := parseGPUMetrics(gpuMetricsFixture(96, 3, 0)); err == nil {
+		t.Fatal("expected error for format revision 3")
+	}
+	if _, err := parseGPUMetrics(gpuMetricsFixture(40, 1, 1)); err == nil {
+		t.Fatal("expected error for truncated v1 table")
+	}
+
+	raw := gpuMetricsFixture(120, 2, 1)
+	if _, err := parseGPUMetrics(raw[:64]); err == nil {
+		t.Fatal("expected error when structure_size exceeds the data read")
+	}
+}
+
+func TestDetectGPUMetricsCards(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeFixture(t, root.Sys, "class/drm/card0/device/uevent", "DRIVER=amdgpu\nPCI_SLOT_NAME=0000:03:00.0\n")
+	writeFixture(t, root.Sys, "class/drm/card0/device/gpu_metrics", string(gpuMetricsFixture(76, 1, 0)))
+	writeFixture(t, root.Sys, "class/drm/card1/device/uevent", "DRIVER=i915\nPCI_SLOT_NAME=0000:00:02.0\n")
+
+	cards := detectGPUMetricsCards(root)
+	if len(cards) != 1 || gpuMetricsPath(cards[0]) != filepath.Join(root.Sys, "class/drm/card0/device/gpu_metrics") {
+		t.Fatalf("detectGPUMetricsCards got %+v", cards)
+	}
+}
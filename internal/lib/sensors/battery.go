@@ -0,0 +1,191 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BatterySnapshot is the state of /sys/class/power_supply. Batteries is
+// empty on machines without a system battery.
+type BatterySnapshot struct {
+	ACOnline  bool
+	Batteries []Battery
+}
+
+// Battery is one system battery. PowerW is positive while charging and
+// negative while discharging. Energies are in Wh; batteries that only
+// report charge (µAh) are converted with voltage_now. HealthPct is
+// energy_full against energy_full_design. HasTimeEstimate is set when the
+// battery reports its own time_to_* estimates or the energy and power
+// readings to derive them from; the estimate for the direction it is not
+// moving in is then 0.
+type Battery struct {
+	Name            string
+	Status          string
+	CapacityPct     float64
+	PowerW          float64
+	EnergyWh        float64
+	EnergyFullWh    float64
+	EnergyDesignWh  float64
+	HealthPct       float64
+	TimeToEmptyMin  float64
+	TimeToFullMin   float64
+	HasTimeEstimate bool
+}
+
+type BatterySampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	snapshot BatterySnapshot
+	root     Root
+}
+
+func NewBatterySampler(interval time.Duration, root Root) *BatterySampler {
+	s := &BatterySampler{root: root}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *BatterySampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot := readPowerSupplies(s.root)
+
+		s.mu.Lock()
+		s.snapshot = snapshot
+		s.mu.Unlock()
+	}
+}
+
+func (s *BatterySampler) Snapshot() BatterySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return BatterySnapshot{ACOnline: s.snapshot.ACOnline, Batteries: append([]Battery(nil), s.snapshot.Batteries...)}
+}
+
+func readPowerSupplies(root Root) BatterySnapshot {
+	var snapshot BatterySnapshot
+
+	dirs, _ := filepath.Glob(root.sys("class", "power_supply", "*"))
+	sort.Slice(dirs, func(i, j int) bool {
+		return naturalLess(filepath.Base(dirs[i]), filepath.Base(dirs[j]))
+	})
+
+	for _, dir := range dirs {
+		// scope=Device marks peripheral batteries (mice, controllers), which
+		// do not power the system.
+		if scope, _ := readTrimmedFile(filepath.Join(dir, "scope")); scope == "Device" {
+			continue
+		}
+
+		kind, _ := readTrimmedFile(filepath.Join(dir, "type"))
+		switch kind {
+		case "Mains", "USB":
+			if online, _ := readTrimmedFile(filepath.Join(dir, "online")); online == "1" {
+				snapshot.ACOnline = true
+			}
+		case "Battery":
+			snapshot.Batteries = append(snapshot.Batteries, readBattery(dir))
+		}
+	}
+
+	return snapshot
+}
+
+func readBattery(dir string) Battery {
+	lookup := func(name string) (float64, bool) {
+		raw, err := readTrimmedFile(filepath.Join(dir, name))
+		if err != nil {
+			return 0, false
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, false
+		}
+		return v, true
+	}
+	read := func(name string) float64 {
+		v, _ := lookup(name)
+		return v
+	}
+
+	b := Battery{Name: filepath.Base(dir), CapacityPct: read("capacity")}
+	b.Status, _ = readTrimmedFile(filepath.Join(dir, "status"))
+
+	// Values are µWh/µW, or µAh/µA for charge-based fuel gauges.
+	voltage := read("voltage_now") / 1e6
+	energyNow, hasEnergy := lookup("energy_now")
+	b.EnergyWh = energyNow / 1e6
+	b.EnergyFullWh = read("energy_full") / 1e6
+	b.EnergyDesignWh = read("energy_full_design") / 1e6
+	if b.EnergyFullWh == 0 && voltage > 0 {
+		chargeNow, hasCharge := lookup("charge_now")
+		b.EnergyWh = chargeNow / 1e6 * voltage
+		b.EnergyFullWh = read("charge_full") / 1e6 * voltage
+		b.EnergyDesignWh = read("charge_full_design") / 1e6 * voltage
+		hasEnergy = hasCharge
+	}
+
+	// Some drivers report current_now/power_now negative while discharging.
+	powerNow, hasPower := lookup("power_now")
+	power := math.Abs(powerNow) / 1e6
+	if power == 0 {
+		currentNow, hasCurrent := lookup("current_now")
+		power = math.Abs(currentNow) / 1e6 * voltage
+		hasPower = hasPower || (hasCurrent && voltage > 0)
+	}
+	b.HasTimeEstimate = hasEnergy && hasPower
+
+	switch b.Status {
+	case "Charging":
+		b.PowerW = power
+		if power > 0 && b.EnergyFullWh > b.EnergyWh {
+			b.TimeToFullMin = 60 * (b.EnergyFullWh - b.EnergyWh) / power
+		}
+	case "Discharging":
+		b.PowerW = -power
+		if power > 0 {
+			b.TimeToEmptyMin = 60 * b.EnergyWh / power
+		}
+	}
+	// Prefer the fuel gauge's own estimates where it has them.
+	if v, ok := lookup("time_to_empty_now"); ok {
+		b.HasTimeEstimate = true
+		if v > 0 {
+			b.TimeToEmptyMin = v / 60
+		}
+	}
+	if v, ok := lookup("time_to_full_now"); ok {
+		b.HasTimeEstimate = true
+		if v > 0 {
+			b.TimeToFullMin = v / 60
+		}
+	}
+
+	if b.CapacityPct == 0 && b.EnergyFullWh > 0 {
+		b.CapacityPct = 100 * b.EnergyWh / b.EnergyFullWh
+	}
+	if b.EnergyDesignWh > 0 {
+		b.HealthPct = 100 * b.EnergyFullWh / b.EnergyDesignWh
+	}
+
+	return b
+}
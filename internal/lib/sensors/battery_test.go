@@ -0,0 +1,77 @@
+package sensors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReadPowerSupplies(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeFixture(t, root.Sys, "class/power_supply/AC/type", "Mains\n")
+	writeFixture(t, root.Sys, "class/power_supply/AC/online", "0\n")
+
+	writeFixture(t, root.Sys, "class/power_supply/BAT0/type", "Battery\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT0/status", "Discharging\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT0/capacity", "80\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT0/energy_now", "40000000\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT0/energy_full", "50000000\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT0/energy_full_design", "57000000\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT0/power_now", "-10000000\n")
+
+	// Steam Deck-style charge-based gauge.
+	writeFixture(t, root.Sys, "class/power_supply/BAT1/type", "Battery\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT1/status", "Charging\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT1/voltage_now", "8000000\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT1/charge_now", "2500000\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT1/charge_full", "5000000\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT1/charge_full_design", "5000000\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT1/current_now", "2500000\n")
+
+	// No power_now or current_now: the gauge cannot estimate a time.
+	writeFixture(t, root.Sys, "class/power_supply/BAT2/type", "Battery\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT2/status", "Unknown\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT2/energy_now", "30000000\n")
+	writeFixture(t, root.Sys, "class/power_supply/BAT2/energy_full", "30000000\n")
+
+	writeFixture(t, root.Sys, "class/power_supply/hidpp_battery_0/type", "Battery\n")
+	writeFixture(t, root.Sys, "class/power_supply/hidpp_battery_0/scope", "Device\n")
+
+	snapshot := readPowerSupplies(root)
+	if snapshot.ACOnline {
+		t.Fatal("AC should be offline")
+	}
+	if len(snapshot.Batteries) != 3 {
+		t.Fatalf("expected 3 system batteries, got %+v", snapshot.Batteries)
+	}
+
+	bat0 := snapshot.Batteries[0]
+	if bat0.Name != "BAT0" || bat0.CapacityPct != 80 || bat0.PowerW != -10 || bat0.EnergyWh != 40 {
+		t.Fatalf("BAT0 got %+v", bat0)
+	}
+	if !bat0.HasTimeEstimate || bat0.TimeToEmptyMin != 240 || bat0.TimeToFullMin != 0 || math.Abs(bat0.HealthPct-100*50.0/57.0) > 1e-9 {
+		t.Fatalf("BAT0 estimates got %+v", bat0)
+	}
+
+	bat1 := snapshot.Batteries[1]
+	if bat1.EnergyWh != 20 || bat1.EnergyFullWh != 40 || bat1.PowerW != 20 || bat1.CapacityPct != 50 {
+		t.Fatalf("BAT1 got %+v", bat1)
+	}
+	if !bat1.HasTimeEstimate || bat1.TimeToFullMin != 60 || bat1.HealthPct != 100 {
+		t.Fatalf("BAT1 estimates got %+v", bat1)
+	}
+
+	if bat2 := snapshot.Batteries[2]; bat2.HasTimeEstimate || bat2.CapacityPct != 100 {
+		t.Fatalf("BAT2 got %+v", bat2)
+	}
+}
+
+func TestReadPowerSuppliesDesktop(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeFixture(t, root.Sys, "class/power_supply/ucsi-source-psy-USBC000:001/type", "USB\n")
+	writeFixture(t, root.Sys, "class/power_supply/ucsi-source-psy-USBC000:001/online", "1\n")
+
+	snapshot := readPowerSupplies(root)
+	if !snapshot.ACOnline || len(snapshot.Batteries) != 0 {
+		t.Fatalf("desktop got %+v", snapshot)
+	}
+}
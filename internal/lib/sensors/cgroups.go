@@ -0,0 +1,311 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCgroupPaths are the cgroups sampled when no paths are configured.
+// Rootful podman and systemd-nspawn place machines under machine.slice.
+var DefaultCgroupPaths = []string{"system.slice", "user.slice", "machine.slice"}
+
+// CgroupSnapshot lists the sampled cgroup v2 groups in configured order.
+type CgroupSnapshot struct {
+	Groups []Cgroup
+}
+
+// Cgroup is one group's usage over the last interval. CPUPct is relative to
+// one core, as in top. MemoryMaxMiB is 0 when memory.max is "max", and so is
+// MemoryPct.
+type Cgroup struct {
+	Path             string
+	CPUPct           float64
+	MemoryMiB        float64
+	MemoryMaxMiB     float64
+	MemoryPct        float64
+	ReadBytesPerSec  float64
+	WriteBytesPerSec float64
+	ReadOpsPerSec    float64
+	WriteOpsPerSec   float64
+}
+
+// cgroupStat holds the cumulative counters of one cgroup read.
+type cgroupStat struct {
+	UsageUsec uint64
+	RBytes    uint64
+	WBytes    uint64
+	RIOs      uint64
+	WIOs      uint64
+}
+
+type CgroupSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	root     Root
+	paths    []string
+	last     map[string]cgroupStat
+	lastAt   time.Time
+	snapshot CgroupSnapshot
+}
+
+// NewCgroupSampler samples the given cgroup paths, relative to
+// /sys/fs/cgroup, every interval. Paths may be globs such as
+// system.slice/docker-*.scope so containers started later are picked up.
+// No paths uses DefaultCgroupPaths.
+func NewCgroupSampler(interval time.Duration, root Root, paths []string) *CgroupSampler {
+	if len(paths) == 0 {
+		paths = DefaultCgroupPaths
+	}
+
+	s := &CgroupSampler{root: root, paths: paths, last: make(map[string]cgroupStat)}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *CgroupSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		var elapsed time.Duration
+		if !s.lastAt.IsZero() {
+			elapsed = now.Sub(s.lastAt)
+		}
+
+		// last and lastAt are only touched by this goroutine.
+		cur := make(map[string]cgroupStat)
+		var snapshot CgroupSnapshot
+		for _, path := range expandCgroupPaths(s.root, s.paths) {
+			group, stat, err := readCgroup(s.root, s.root.sys("fs", "cgroup", path))
+			if err != nil {
+				continue
+			}
+			group.Path = path
+
+			if prev, ok := s.last[path]; ok {
+				cgroupRates(&group, prev, stat, elapsed)
+			}
+			cur[path] = stat
+			snapshot.Groups = append(snapshot.Groups, group)
+		}
+		s.last = cur
+		s.lastAt = now
+
+		s.mu.Lock()
+		s.snapshot = snapshot
+		s.mu.Unlock()
+	}
+}
+
+func (s *CgroupSampler) Snapshot() CgroupSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return CgroupSnapshot{Groups: append([]Cgroup(nil), s.snapshot.Groups...)}
+}
+
+// expandCgroupPaths resolves globs against the cgroup2 mount and drops
+// duplicates, keeping the first occurrence.
+func expandCgroupPaths(root Root, patterns []string) []string {
+	base := root.sys("fs", "cgroup")
+	seen := make(map[string]bool)
+	var paths []string
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(base, pattern))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(base, match)
+			if err != nil || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			paths = append(paths, rel)
+		}
+	}
+
+	return paths
+}
+
+// readCgroup reads the memory gauges of dir into a Cgroup and the
+// cumulative cpu/io counters into a cgroupStat. Only cpu.stat is required;
+// memory.* and io.stat are missing when those controllers are not enabled
+// for the group.
+func readCgroup(root Root, dir string) (Cgroup, cgroupStat, error) {
+	var group Cgroup
+	var stat cgroupStat
+
+	cpu, err := readFlatKeyed(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return Cgroup{}, cgroupStat{}, err
+	}
+	stat.UsageUsec = cpu["usage_usec"]
+
+	if current, err := readUintFromFile(filepath.Join(dir, "memory.current")); err == nil {
+		group.MemoryMiB = float64(current) / bytesPerMiB
+	}
+	if raw, err := readTrimmedFile(filepath.Join(dir, "memory.max")); err == nil && raw != "max" {
+		if limit, err := strconv.ParseUint(raw, 10, 64); err == nil && limit > 0 {
+			group.MemoryMaxMiB = float64(limit) / bytesPerMiB
+			group.MemoryPct = 100 * group.MemoryMiB / group.MemoryMaxMiB
+		}
+	}
+
+	physical := func(devID string) bool {
+		return isPhysicalDisk(root, devID)
+	}
+	if io, err := readIOStat(filepath.Join(dir, "io.stat"), physical); err == nil {
+		stat.RBytes = io.RBytes
+		stat.WBytes = io.WBytes
+		stat.RIOs = io.RIOs
+		stat.WIOs = io.WIOs
+	}
+
+	return group, stat, nil
+}
+
+// cgroupRates fills the per-second fields of group from two reads taken
+// elapsed apart.
+func cgroupRates(group *Cgroup, prev cgroupStat, cur cgroupStat, elapsed time.Duration) {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return
+	}
+
+	group.CPUPct = 100 * float64(counterDelta(prev.UsageUsec, cur.UsageUsec)) / float64(elapsed.Microseconds())
+	group.ReadBytesPerSec = float64(counterDelta(prev.RBytes, cur.RBytes)) / seconds
+	group.WriteBytesPerSec = float64(counterDelta(prev.WBytes, cur.WBytes)) / seconds
+	group.ReadOpsPerSec = float64(counterDelta(prev.RIOs, cur.RIOs)) / seconds
+	group.WriteOpsPerSec = float64(counterDelta(prev.WIOs, cur.WIOs)) / seconds
+}
+
+// readFlatKeyed parses a cgroup "key value" per line file such as cpu.stat.
+func readFlatKeyed(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, raw, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			continue
+		}
+		values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// readIOStat sums io.stat over the devices keep accepts, e.g.
+// "259:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0".
+func readIOStat(path string, keep func(devID string) bool) (cgroupStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cgroupStat{}, err
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	var stat cgroupStat
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !keep(fields[0]) {
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, raw, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			value, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			switch key {
+			case "rbytes":
+				stat.RBytes += value
+			case "wbytes":
+				stat.WBytes += value
+			case "rios":
+				stat.RIOs += value
+			case "wios":
+				stat.WIOs += value
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return cgroupStat{}, err
+	}
+
+	return stat, nil
+}
+
+// isPhysicalDisk reports whether the maj:min block device is a whole disk,
+// as the disks section counts them, that is not stacked on other devices.
+// The kernel charges a cgroup's I/O to a device-mapper (LVM, LUKS) or md
+// device and again to its members, which it lists under slaves/, so only
+// the bottom of the stack is counted.
+func isPhysicalDisk(root Root, devID string) bool {
+	target, err := os.Readlink(root.sys("dev", "block", devID))
+	if err != nil {
+		return false
+	}
+
+	blockDir := root.sys("block")
+	name := filepath.Base(target)
+	if !isWholeDisk(blockDir, name) {
+		return false
+	}
+
+	slaves, _ := os.ReadDir(filepath.Join(blockDir, name, "slaves"))
+	return len(slaves) == 0
+}
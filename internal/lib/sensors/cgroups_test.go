@@ -0,0 +1,122 @@
+package sensors
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeCgroupFixture(t *testing.T, root Root) {
+	t.Helper()
+
+	writeFixture(t, root.Sys, "fs/cgroup/system.slice/cpu.stat", "usage_usec 5000000\nuser_usec 4000000\nsystem_usec 1000000\n")
+	writeFixture(t, root.Sys, "fs/cgroup/system.slice/memory.current", "1073741824\n")
+	writeFixture(t, root.Sys, "fs/cgroup/system.slice/memory.max", "max\n")
+	writeFixture(t, root.Sys, "fs/cgroup/system.slice/io.stat", ""+
+		"259:0 rbytes=1048576 wbytes=2097152 rios=10 wios=20 dbytes=0 dios=0\n"+
+		"8:0 rbytes=1048576 wbytes=0 rios=5 wios=0 dbytes=0 dios=0\n"+
+		"253:0 rbytes=1048576 wbytes=2097152 rios=10 wios=20 dbytes=0 dios=0\n"+
+		"7:0 rbytes=4096 wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n")
+
+	// dm-0 is an LVM volume on nvme0n1, so its I/O is already in 259:0's
+	// line; loop0 is backed by a file on one of the disks.
+	writeBlockDevice(t, root, "259:0", "nvme0n1")
+	writeBlockDevice(t, root, "8:0", "sda")
+	writeBlockDevice(t, root, "253:0", "dm-0")
+	writeFixture(t, root.Sys, "block/dm-0/slaves/nvme0n1p2", "")
+	writeBlockDevice(t, root, "7:0", "loop0")
+
+	writeFixture(t, root.Sys, "fs/cgroup/system.slice/docker-abc.scope/cpu.stat", "usage_usec 100\n")
+	writeFixture(t, root.Sys, "fs/cgroup/system.slice/docker-abc.scope/memory.current", "268435456\n")
+	writeFixture(t, root.Sys, "fs/cgroup/system.slice/docker-abc.scope/memory.max", "536870912\n")
+	writeFixture(t, root.Sys, "fs/cgroup/system.slice/docker-def.scope/cpu.stat", "usage_usec 200\n")
+}
+
+// writeBlockDevice adds /sys/block/<name> and the /sys/dev/block/<devID>
+// link pointing at it.
+func writeBlockDevice(t *testing.T, root Root, devID string, name string) {
+	t.Helper()
+
+	writeFixture(t, root.Sys, "block/"+name+"/dev", devID+"\n")
+	link := filepath.Join(root.Sys, "dev", "block", devID)
+	if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", filepath.Dir(link), err)
+	}
+	if err := os.Symlink("../../block/"+name, link); err != nil {
+		t.Fatalf("symlink %s: %v", link, err)
+	}
+}
+
+func TestReadCgroup(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeCgroupFixture(t, root)
+
+	group, stat, err := readCgroup(root, root.sys("fs", "cgroup", "system.slice"))
+	if err != nil {
+		t.Fatalf("readCgroup error: %v", err)
+	}
+
+	if group.MemoryMiB != 1024 || group.MemoryMaxMiB != 0 || group.MemoryPct != 0 {
+		t.Fatalf("readCgroup memory got %+v", group)
+	}
+	want := cgroupStat{UsageUsec: 5000000, RBytes: 2097152, WBytes: 2097152, RIOs: 15, WIOs: 20}
+	if stat != want {
+		t.Fatalf("readCgroup stat got %+v, want %+v", stat, want)
+	}
+
+	group, _, err = readCgroup(root, root.sys("fs", "cgroup", "system.slice", "docker-abc.scope"))
+	if err != nil {
+		t.Fatalf("readCgroup scope error: %v", err)
+	}
+	if group.MemoryMiB != 256 || group.MemoryMaxMiB != 512 || group.MemoryPct != 50 {
+		t.Fatalf("readCgroup scope memory got %+v", group)
+	}
+}
+
+func TestReadCgroupMissing(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	if _, _, err := readCgroup(root, root.sys("fs", "cgroup", "user.slice")); err == nil {
+		t.Fatal("expected error for missing cgroup")
+	}
+}
+
+func TestIsPhysicalDisk(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeCgroupFixture(t, root)
+
+	for devID, want := range map[string]bool{"259:0": true, "8:0": true, "253:0": false, "7:0": false, "8:1": false} {
+		if got := isPhysicalDisk(root, devID); got != want {
+			t.Fatalf("isPhysicalDisk(%s)=%v, want %v", devID, got, want)
+		}
+	}
+}
+
+func TestExpandCgroupPaths(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeCgroupFixture(t, root)
+
+	got := expandCgroupPaths(root, []string{"system.slice/docker-*.scope", "/system.slice/", "user.slice", "system.slice/docker-abc.scope"})
+	want := []string{"system.slice/docker-abc.scope", "system.slice/docker-def.scope", "system.slice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expandCgroupPaths got %v, want %v", got, want)
+	}
+}
+
+func TestCgroupRates(t *testing.T) {
+	prev := cgroupStat{UsageUsec: 1000000, RBytes: 0, WBytes: 1000, RIOs: 0, WIOs: 10}
+	cur := cgroupStat{UsageUsec: 2500000, RBytes: 4096, WBytes: 3000, RIOs: 2, WIOs: 30}
+
+	var group Cgroup
+	cgroupRates(&group, prev, cur, 2*time.Second)
+
+	// 1.5s of CPU over 2s is 75% of one core.
+	if math.Abs(group.CPUPct-75) > 1e-9 {
+		t.Fatalf("CPUPct got %v, want 75", group.CPUPct)
+	}
+	if group.ReadBytesPerSec != 2048 || group.WriteBytesPerSec != 1000 || group.ReadOpsPerSec != 1 || group.WriteOpsPerSec != 10 {
+		t.Fatalf("cgroupRates got %+v", group)
+	}
+}
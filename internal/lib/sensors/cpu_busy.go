@@ -4,6 +4,7 @@ package sensors
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -13,43 +14,81 @@ import (
 )
 
 type CPUBusySampler struct {
-	mu        sync.RWMutex
-	lastIdle  uint64
-	lastTotal uint64
-	utilPct   float64
+	lifecycle
+
+	mu       sync.RWMutex
+	statPath string
+	last     procStat
+	snapshot CPUBusySnapshot
 }
 
 type CPUBusySnapshot struct {
 	UtilPct float64
+	CPUTimesPct
+	Cores []CPUCoreBusy
+}
+
+// CPUTimesPct breaks utilization down by where the time was spent, as a
+// percentage of the interval. Nice time is folded into UserPct and softirq
+// into IRQPct.
+type CPUTimesPct struct {
+	UserPct   float64
+	SystemPct float64
+	IOWaitPct float64
+	IRQPct    float64
+	StealPct  float64
+}
+
+type CPUCoreBusy struct {
+	ID      int
+	UtilPct float64
+	CPUTimesPct
+}
+
+type cpuTimes struct {
+	User    uint64
+	Nice    uint64
+	System  uint64
+	Idle    uint64
+	IOWait  uint64
+	IRQ     uint64
+	SoftIRQ uint64
+	Steal   uint64
+}
+
+type procStat struct {
+	Total cpuTimes
+	Cores map[int]cpuTimes
 }
 
-func NewCPUBusySampler(interval time.Duration) *CPUBusySampler {
-	s := &CPUBusySampler{}
-	go s.run(interval)
+func NewCPUBusySampler(interval time.Duration, root Root) *CPUBusySampler {
+	s := &CPUBusySampler{statPath: root.proc("stat")}
+	s.onStart(interval, s.run)
 
 	return s
 }
 
-func (s *CPUBusySampler) run(interval time.Duration) {
+func (s *CPUBusySampler) run(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		idle, total, err := readProcStat()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stat, err := readProcStat(s.statPath)
 		if err != nil {
 			continue
 		}
 
 		s.mu.Lock()
-		if s.lastTotal != 0 {
-			idleDelta := idle - s.lastIdle
-			totalDelta := total - s.lastTotal
-			if totalDelta > 0 {
-				s.utilPct = 100.0 * float64(totalDelta-idleDelta) / float64(totalDelta)
-			}
+		if s.last.Total.total() != 0 {
+			s.snapshot = cpuBusySnapshot(s.last, stat)
 		}
-		s.lastIdle = idle
-		s.lastTotal = total
+		s.last = stat
 		s.mu.Unlock()
 	}
 }
@@ -58,13 +97,81 @@ func (s *CPUBusySampler) Snapshot() CPUBusySnapshot {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return CPUBusySnapshot{UtilPct: s.utilPct}
+	snapshot := s.snapshot
+	snapshot.Cores = append([]CPUCoreBusy(nil), s.snapshot.Cores...)
+	return snapshot
+}
+
+func cpuBusySnapshot(prev procStat, cur procStat) CPUBusySnapshot {
+	utilPct, times := cpuTimesDelta(prev.Total, cur.Total)
+	snapshot := CPUBusySnapshot{UtilPct: utilPct, CPUTimesPct: times}
+
+	for id := 0; id <= maxCoreID(cur.Cores); id++ {
+		curCore, ok := cur.Cores[id]
+		if !ok {
+			continue
+		}
+		prevCore, ok := prev.Cores[id]
+		if !ok {
+			continue
+		}
+
+		utilPct, times := cpuTimesDelta(prevCore, curCore)
+		snapshot.Cores = append(snapshot.Cores, CPUCoreBusy{ID: id, UtilPct: utilPct, CPUTimesPct: times})
+	}
+
+	return snapshot
+}
+
+func cpuTimesDelta(prev cpuTimes, cur cpuTimes) (float64, CPUTimesPct) {
+	totalDelta := float64(counterDelta(prev.total(), cur.total()))
+	if totalDelta <= 0 {
+		return 0, CPUTimesPct{}
+	}
+
+	pct := func(prev, cur uint64) float64 {
+		return 100.0 * float64(counterDelta(prev, cur)) / totalDelta
+	}
+
+	utilPct := 100.0 - pct(prev.Idle, cur.Idle)
+	return utilPct, CPUTimesPct{
+		UserPct:   pct(prev.User+prev.Nice, cur.User+cur.Nice),
+		SystemPct: pct(prev.System, cur.System),
+		IOWaitPct: pct(prev.IOWait, cur.IOWait),
+		IRQPct:    pct(prev.IRQ+prev.SoftIRQ, cur.IRQ+cur.SoftIRQ),
+		StealPct:  pct(prev.Steal, cur.Steal),
+	}
+}
+
+// counterDelta returns cur-prev, or 0 when the counter went backwards
+// (e.g. a CPU was hot-unplugged and came back).
+func counterDelta(prev uint64, cur uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+
+	return cur - prev
+}
+
+func (t cpuTimes) total() uint64 {
+	return t.User + t.Nice + t.System + t.Idle + t.IOWait + t.IRQ + t.SoftIRQ + t.Steal
 }
 
-func readProcStat() (idle uint64, total uint64, err error) {
-	f, err := os.Open("/proc/stat")
+func maxCoreID(cores map[int]cpuTimes) int {
+	highest := -1
+	for id := range cores {
+		if id > highest {
+			highest = id
+		}
+	}
+
+	return highest
+}
+
+func readProcStat(path string) (procStat, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return 0, 0, err
+		return procStat{}, err
 	}
 	defer func() {
 		err := f.Close()
@@ -73,26 +180,56 @@ func readProcStat() (idle uint64, total uint64, err error) {
 		}
 	}()
 
+	stat := procStat{Cores: make(map[int]cpuTimes)}
 	scanner := bufio.NewScanner(f)
-	if !scanner.Scan() {
-		return 0, 0, scanner.Err()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		times, ok := parseCPUTimes(fields[1:])
+		if !ok {
+			continue
+		}
+
+		if fields[0] == "cpu" {
+			stat.Total = times
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(fields[0], "cpu"))
+		if err != nil {
+			continue
+		}
+		stat.Cores[id] = times
 	}
 
-	fields := strings.Fields(scanner.Text())
-	if len(fields) < 8 {
-		return 0, 0, nil
+	if err := scanner.Err(); err != nil {
+		return procStat{}, err
 	}
 
-	var values []uint64
-	for _, v := range fields[1:8] {
-		n, _ := strconv.ParseUint(v, 10, 64)
-		values = append(values, n)
+	return stat, nil
+}
+
+func parseCPUTimes(fields []string) (cpuTimes, bool) {
+	if len(fields) < 7 {
+		return cpuTimes{}, false
 	}
 
-	idle = values[3]
-	for _, v := range values {
-		total += v
+	var values [8]uint64
+	for i := 0; i < len(values) && i < len(fields); i++ {
+		values[i], _ = strconv.ParseUint(fields[i], 10, 64)
 	}
 
-	return idle, total, nil
+	return cpuTimes{
+		User:    values[0],
+		Nice:    values[1],
+		System:  values[2],
+		Idle:    values[3],
+		IOWait:  values[4],
+		IRQ:     values[5],
+		SoftIRQ: values[6],
+		Steal:   values[7],
+	}, true
 }
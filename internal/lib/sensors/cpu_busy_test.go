@@ -0,0 +1,51 @@
+package sensors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCPUBusySnapshotPerCore(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "stat.0", `cpu  200 0 100 1600 0 0 0 0 0 0
+cpu0 100 0 50 800 0 0 0 0 0 0
+cpu1 100 0 50 800 0 0 0 0 0 0
+intr 12345
+`)
+	writeFixture(t, dir, "stat.1", `cpu  400 10 110 1760 10 5 5 0 0 0
+cpu0 280 10 60 800 0 0 0 0 0 0
+cpu1 120 0 50 960 10 5 5 0 0 0
+intr 12399
+`)
+
+	prev, err := readProcStat(dir + "/stat.0")
+	if err != nil {
+		t.Fatalf("readProcStat error: %v", err)
+	}
+	cur, err := readProcStat(dir + "/stat.1")
+	if err != nil {
+		t.Fatalf("readProcStat error: %v", err)
+	}
+
+	snapshot := cpuBusySnapshot(prev, cur)
+
+	if math.Abs(snapshot.UtilPct-60) > 1e-9 {
+		t.Fatalf("aggregate UtilPct got %v, want 60", snapshot.UtilPct)
+	}
+	if math.Abs(snapshot.UserPct-52.5) > 1e-9 || math.Abs(snapshot.IRQPct-2.5) > 1e-9 {
+		t.Fatalf("aggregate breakdown got %+v", snapshot.CPUTimesPct)
+	}
+	if len(snapshot.Cores) != 2 {
+		t.Fatalf("expected 2 cores, got %+v", snapshot.Cores)
+	}
+
+	pegged := snapshot.Cores[0]
+	if pegged.ID != 0 || math.Abs(pegged.UtilPct-100) > 1e-9 || math.Abs(pegged.UserPct-95) > 1e-9 {
+		t.Fatalf("core 0 got %+v, want 100%% util with 95%% user", pegged)
+	}
+
+	quiet := snapshot.Cores[1]
+	if quiet.ID != 1 || math.Abs(quiet.UtilPct-20) > 1e-9 || math.Abs(quiet.IOWaitPct-5) > 1e-9 {
+		t.Fatalf("core 1 got %+v, want 20%% util with 5%% iowait", quiet)
+	}
+}
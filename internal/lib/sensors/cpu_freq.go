@@ -0,0 +1,137 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type CPUFreqSnapshot struct {
+	AvgMHz                      float64
+	MaxMHz                      float64
+	Governor                    string
+	EnergyPerformancePreference string
+	Cores                       []CPUCoreFreq
+}
+
+// CPUCoreFreq is the current clock of one core alongside the limits cpufreq
+// reports for it. Limits the driver does not expose are left at 0.
+type CPUCoreFreq struct {
+	ID      int
+	CurMHz  float64
+	MinMHz  float64
+	MaxMHz  float64
+	BaseMHz float64
+}
+
+type CPUFreqSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	snapshot CPUFreqSnapshot
+	cpuDir   string
+}
+
+func NewCPUFreqSampler(interval time.Duration, root Root) *CPUFreqSampler {
+	s := &CPUFreqSampler{cpuDir: root.sys("devices", "system", "cpu")}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *CPUFreqSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot, err := readCPUFreq(s.cpuDir)
+		if err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		s.snapshot = snapshot
+		s.mu.Unlock()
+	}
+}
+
+func (s *CPUFreqSampler) Snapshot() CPUFreqSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := s.snapshot
+	snapshot.Cores = append([]CPUCoreFreq(nil), s.snapshot.Cores...)
+	return snapshot
+}
+
+func readCPUFreq(cpuDir string) (CPUFreqSnapshot, error) {
+	dirs, err := filepath.Glob(filepath.Join(cpuDir, "cpu[0-9]*", "cpufreq"))
+	if err != nil {
+		return CPUFreqSnapshot{}, err
+	}
+
+	var snapshot CPUFreqSnapshot
+	var sum float64
+	for _, dir := range dirs {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(filepath.Dir(dir)), "cpu"))
+		if err != nil {
+			continue
+		}
+
+		curKHz, err := readUintFromFile(filepath.Join(dir, "scaling_cur_freq"))
+		if err != nil {
+			continue
+		}
+
+		core := CPUCoreFreq{
+			ID:      id,
+			CurMHz:  float64(curKHz) / 1000.0,
+			MinMHz:  readKHzAsMHz(filepath.Join(dir, "cpuinfo_min_freq")),
+			MaxMHz:  readKHzAsMHz(filepath.Join(dir, "cpuinfo_max_freq")),
+			BaseMHz: readKHzAsMHz(filepath.Join(dir, "base_frequency")),
+		}
+		snapshot.Cores = append(snapshot.Cores, core)
+
+		sum += core.CurMHz
+		if core.CurMHz > snapshot.MaxMHz {
+			snapshot.MaxMHz = core.CurMHz
+		}
+
+		if snapshot.Governor == "" {
+			snapshot.Governor, _ = readTrimmedFile(filepath.Join(dir, "scaling_governor"))
+		}
+		if snapshot.EnergyPerformancePreference == "" {
+			snapshot.EnergyPerformancePreference, _ = readTrimmedFile(filepath.Join(dir, "energy_performance_preference"))
+		}
+	}
+
+	if len(snapshot.Cores) > 0 {
+		snapshot.AvgMHz = sum / float64(len(snapshot.Cores))
+	}
+	sort.Slice(snapshot.Cores, func(i, j int) bool {
+		return snapshot.Cores[i].ID < snapshot.Cores[j].ID
+	})
+
+	return snapshot, nil
+}
+
+func readKHzAsMHz(path string) float64 {
+	khz, err := readUintFromFile(path)
+	if err != nil {
+		return 0
+	}
+
+	return float64(khz) / 1000.0
+}
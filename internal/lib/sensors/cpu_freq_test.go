@@ -0,0 +1,38 @@
+package sensors
+
+import "testing"
+
+func TestReadCPUFreq(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+
+	writeFixture(t, root.Sys, "devices/system/cpu/cpu0/cpufreq/scaling_cur_freq", "4850000\n")
+	writeFixture(t, root.Sys, "devices/system/cpu/cpu0/cpufreq/cpuinfo_min_freq", "400000\n")
+	writeFixture(t, root.Sys, "devices/system/cpu/cpu0/cpufreq/cpuinfo_max_freq", "5100000\n")
+	writeFixture(t, root.Sys, "devices/system/cpu/cpu0/cpufreq/scaling_governor", "powersave\n")
+	writeFixture(t, root.Sys, "devices/system/cpu/cpu0/cpufreq/energy_performance_preference", "balance_performance\n")
+	writeFixture(t, root.Sys, "devices/system/cpu/cpu10/cpufreq/scaling_cur_freq", "1150000\n")
+	writeFixture(t, root.Sys, "devices/system/cpu/cpu10/cpufreq/cpuinfo_max_freq", "5100000\n")
+	writeFixture(t, root.Sys, "devices/system/cpu/cpu2/cpufreq/scaling_cur_freq", "3000000\n")
+	writeFixture(t, root.Sys, "devices/system/cpu/cpufreq/boost", "1\n")
+
+	snapshot, err := readCPUFreq(root.sys("devices", "system", "cpu"))
+	if err != nil {
+		t.Fatalf("readCPUFreq error: %v", err)
+	}
+
+	if len(snapshot.Cores) != 3 {
+		t.Fatalf("expected 3 cores, got %+v", snapshot.Cores)
+	}
+	if snapshot.Cores[0].ID != 0 || snapshot.Cores[1].ID != 2 || snapshot.Cores[2].ID != 10 {
+		t.Fatalf("cores not sorted by id: %+v", snapshot.Cores)
+	}
+	if snapshot.Cores[0].CurMHz != 4850 || snapshot.Cores[0].MinMHz != 400 || snapshot.Cores[0].MaxMHz != 5100 {
+		t.Fatalf("cpu0 got %+v", snapshot.Cores[0])
+	}
+	if snapshot.AvgMHz != 3000 || snapshot.MaxMHz != 4850 {
+		t.Fatalf("avg/max got %v/%v, want 3000/4850", snapshot.AvgMHz, snapshot.MaxMHz)
+	}
+	if snapshot.Governor != "powersave" || snapshot.EnergyPerformancePreference != "balance_performance" {
+		t.Fatalf("governor/epp got %q/%q", snapshot.Governor, snapshot.EnergyPerformancePreference)
+	}
+}
@@ -3,61 +3,127 @@
 package sensors
 
 import (
+	"context"
+	"errors"
+	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+var errNoRAPLDomains = errors.New("no RAPL powercap domains found")
+
+// raplZonePattern matches powercap zone directories such as intel-rapl:0,
+// intel-rapl:0:1 or amd-rapl:1. MMIO zones (intel-rapl-mmio:0) are matched
+// too and only used when they do not duplicate an MSR zone.
+var raplZonePattern = regexp.MustCompile(`^([a-z]+-rapl(?:-mmio)?):(\d+)(?::(\d+))?$`)
+
 type CPUPowerSampler struct {
-	mu         sync.RWMutex
-	lastEnergy uint64
-	powerW     float64
-	energyPath string
-	maxPath    string
+	lifecycle
+
+	mu      sync.RWMutex
+	domains []raplDomain
+	powerW  float64
 }
 
+// CPUPowerSnapshot holds the CPU package power, summed across sockets, and
+// the power of every RAPL domain that could be read.
 type CPUPowerSnapshot struct {
+	PowerW  float64
+	Domains []CPUPowerDomain
+}
+
+// CPUPowerDomain is the power drawn by one powercap zone, e.g. package-0,
+// core, uncore, dram or psys.
+type CPUPowerDomain struct {
+	Zone   string
+	Name   string
 	PowerW float64
 }
 
-func NewCPUPowerSampler(interval time.Duration) *CPUPowerSampler {
-	path := detectRAPLPackagePath()
-	s := &CPUPowerSampler{}
-	if path != "" {
-		s.energyPath = filepath.Join(path, "energy_uj")
-		s.maxPath = filepath.Join(path, "max_energy_range_uj")
-		go s.run(interval)
+type raplDomain struct {
+	zone       string
+	name       string
+	energyPath string
+	maxPath    string
+	lastEnergy uint64
+	lastAt     time.Time
+	powerW     float64
+}
+
+func NewCPUPowerSampler(interval time.Duration, root Root) *CPUPowerSampler {
+	domains, err := detectRAPLDomains(root)
+	s := &CPUPowerSampler{domains: domains}
+	if err != nil {
+		log.Printf("warning: cpu power unavailable: %v", err)
+		return s
 	}
 
+	s.onStart(interval, s.run)
+
 	return s
 }
 
-func (s *CPUPowerSampler) run(interval time.Duration) {
+func (s *CPUPowerSampler) run(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		energy, max, err := readEnergy(s.energyPath, s.maxPath)
-		if err != nil {
-			continue
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 
 		s.mu.Lock()
-		if s.lastEnergy != 0 {
-			delta := energy - s.lastEnergy
-			if energy < s.lastEnergy && max > 0 {
-				delta = (max - s.lastEnergy) + energy
+		s.powerW = 0
+		for i := range s.domains {
+			d := &s.domains[i]
+			energy, max, err := readEnergy(d.energyPath, d.maxPath)
+			if err != nil {
+				continue
+			}
+			now := time.Now()
+
+			// Divide by the time since this domain was last read, not
+			// interval, so a late tick does not inflate the power.
+			if d.lastEnergy != 0 {
+				d.powerW = energyDeltaWatts(d.lastEnergy, energy, max, now.Sub(d.lastAt))
+			}
+			d.lastEnergy = energy
+			d.lastAt = now
+
+			if isRAPLPackage(d.name) {
+				s.powerW += d.powerW
 			}
-			s.powerW = float64(delta) / interval.Seconds() / 1_000_000.0
 		}
-		s.lastEnergy = energy
 		s.mu.Unlock()
 	}
 }
 
+// energyDeltaWatts converts two energy_uj readings taken elapsed apart into
+// watts, accounting for the counter wrapping at max_energy_range_uj.
+func energyDeltaWatts(prev uint64, cur uint64, max uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	delta := cur - prev
+	if cur < prev {
+		if max == 0 {
+			return 0
+		}
+		delta = (max - prev) + cur
+	}
+
+	return float64(delta) / elapsed.Seconds() / 1_000_000.0
+}
+
 func readEnergy(energyPath string, maxPath string) (uint64, uint64, error) {
 	energyRaw, err := os.ReadFile(energyPath)
 	if err != nil {
@@ -84,10 +150,102 @@ func (s *CPUPowerSampler) Snapshot() CPUPowerSnapshot {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return CPUPowerSnapshot{PowerW: s.powerW}
+	snapshot := CPUPowerSnapshot{PowerW: s.powerW}
+	for _, d := range s.domains {
+		snapshot.Domains = append(snapshot.Domains, CPUPowerDomain{Zone: d.zone, Name: d.name, PowerW: d.powerW})
+	}
+
+	return snapshot
+}
+
+// detectRAPLDomains finds every readable RAPL zone and subzone under
+// /sys/class/powercap, regardless of whether the kernel names them
+// intel-rapl or amd-rapl.
+func detectRAPLDomains(root Root) ([]raplDomain, error) {
+	entries, err := os.ReadDir(root.sys("class", "powercap"))
+	if err != nil {
+		return nil, err
+	}
+
+	type zoneKey struct {
+		mmio bool
+		ids  [2]int
+	}
+	keys := make(map[string]zoneKey)
+	var zones []string
+	for _, entry := range entries {
+		m := raplZonePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+
+		key := zoneKey{mmio: strings.HasSuffix(m[1], "-mmio"), ids: [2]int{0, -1}}
+		key.ids[0], _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			key.ids[1], _ = strconv.Atoi(m[3])
+		}
+		keys[entry.Name()] = key
+		zones = append(zones, entry.Name())
+	}
+
+	// MSR zones first so an MMIO duplicate of package-0 is the one dropped,
+	// then package before its subzones.
+	sort.Slice(zones, func(i, j int) bool {
+		a, b := keys[zones[i]], keys[zones[j]]
+		if a.mmio != b.mmio {
+			return !a.mmio
+		}
+		if a.ids[0] != b.ids[0] {
+			return a.ids[0] < b.ids[0]
+		}
+		return a.ids[1] < b.ids[1]
+	})
+
+	var domains []raplDomain
+	var readErr error
+	seen := make(map[string]bool)
+	for _, zone := range zones {
+		dir := root.sys("class", "powercap", zone)
+		name, err := readTrimmedFile(filepath.Join(dir, "name"))
+		if err != nil || name == "" {
+			name = zone
+		}
+
+		parent := ""
+		if keys[zone].ids[1] >= 0 {
+			parent = strconv.Itoa(keys[zone].ids[0])
+		}
+		if keys[zone].mmio && seen[parent+"/"+name] {
+			continue
+		}
+
+		d := raplDomain{
+			zone:       zone,
+			name:       name,
+			energyPath: filepath.Join(dir, "energy_uj"),
+			maxPath:    filepath.Join(dir, "max_energy_range_uj"),
+		}
+		if _, _, err := readEnergy(d.energyPath, d.maxPath); err != nil {
+			readErr = err
+			continue
+		}
+
+		seen[parent+"/"+name] = true
+		domains = append(domains, d)
+	}
+
+	if len(domains) == 0 {
+		// energy_uj is root-only on most distros; surface that rather than
+		// reporting 0 W.
+		if readErr != nil {
+			return nil, readErr
+		}
+		return nil, errNoRAPLDomains
+	}
+
+	return domains, nil
 }
 
-func detectRAPLPackagePath() string {
-	name := "intel-rapl:0"
-	return filepath.Join("/sys/class/powercap", name)
+func isRAPLPackage(name string) bool {
+	return strings.HasPrefix(name, "package-")
 }
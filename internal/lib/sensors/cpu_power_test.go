@@ -0,0 +1,93 @@
+package sensors
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func writeRAPLZone(t *testing.T, root Root, zone string, name string) {
+	t.Helper()
+
+	writeFixture(t, root.Sys, "class/powercap/"+zone+"/name", name+"\n")
+	writeFixture(t, root.Sys, "class/powercap/"+zone+"/energy_uj", "5000000\n")
+	writeFixture(t, root.Sys, "class/powercap/"+zone+"/max_energy_range_uj", "65532610987\n")
+}
+
+func TestDetectRAPLDomainsMultiSocket(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeRAPLZone(t, root, "intel-rapl:1", "package-1")
+	writeRAPLZone(t, root, "intel-rapl:0", "package-0")
+	writeRAPLZone(t, root, "intel-rapl:0:0", "core")
+	writeRAPLZone(t, root, "intel-rapl:0:1", "dram")
+	writeRAPLZone(t, root, "intel-rapl:1:0", "core")
+	writeRAPLZone(t, root, "intel-rapl-mmio:0", "package-0")
+	writeRAPLZone(t, root, "intel-rapl:2", "psys")
+	writeFixture(t, root.Sys, "class/powercap/intel-rapl/enabled", "1\n")
+
+	domains, err := detectRAPLDomains(root)
+	if err != nil {
+		t.Fatalf("detectRAPLDomains error: %v", err)
+	}
+
+	var got []string
+	for _, d := range domains {
+		got = append(got, d.zone+"="+d.name)
+	}
+	want := []string{
+		"intel-rapl:0=package-0",
+		"intel-rapl:0:0=core",
+		"intel-rapl:0:1=dram",
+		"intel-rapl:1=package-1",
+		"intel-rapl:1:0=core",
+		"intel-rapl:2=psys",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("detectRAPLDomains got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("detectRAPLDomains got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDetectRAPLDomainsAMDNaming(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeRAPLZone(t, root, "amd-rapl:0", "package-0")
+	writeRAPLZone(t, root, "amd-rapl:0:0", "core")
+
+	domains, err := detectRAPLDomains(root)
+	if err != nil {
+		t.Fatalf("detectRAPLDomains error: %v", err)
+	}
+	if len(domains) != 2 || domains[0].name != "package-0" || domains[1].name != "core" {
+		t.Fatalf("detectRAPLDomains got %+v", domains)
+	}
+}
+
+func TestDetectRAPLDomainsNone(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeFixture(t, root.Sys, "class/powercap/intel-rapl/enabled", "1\n")
+
+	if _, err := detectRAPLDomains(root); !errors.Is(err, errNoRAPLDomains) {
+		t.Fatalf("detectRAPLDomains error got %v, want errNoRAPLDomains", err)
+	}
+}
+
+func TestEnergyDeltaWatts(t *testing.T) {
+	if got := energyDeltaWatts(1_000_000, 46_000_000, 0, time.Second); math.Abs(got-45) > 1e-9 {
+		t.Fatalf("energyDeltaWatts got %v, want 45", got)
+	}
+	if got := energyDeltaWatts(99_000_000, 9_000_000, 100_000_000, 2*time.Second); math.Abs(got-5) > 1e-9 {
+		t.Fatalf("energyDeltaWatts wrap got %v, want 5", got)
+	}
+	// A tick that fired 500ms late covers 1.5s of energy.
+	if got := energyDeltaWatts(1_000_000, 46_000_000, 0, 1500*time.Millisecond); math.Abs(got-30) > 1e-9 {
+		t.Fatalf("energyDeltaWatts late tick got %v, want 30", got)
+	}
+	if got := energyDeltaWatts(1_000_000, 46_000_000, 0, 0); got != 0 {
+		t.Fatalf("energyDeltaWatts without elapsed time got %v, want 0", got)
+	}
+}
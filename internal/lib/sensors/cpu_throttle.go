@@ -0,0 +1,229 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CPUThrottleSourceThermal means the counts come from the Intel
+	// thermal_throttle event counters.
+	CPUThrottleSourceThermal = "thermal_throttle"
+	// CPUThrottleSourceFrequency means throttling is inferred from busy
+	// cores running well below their max clock, for CPUs (AMD) without
+	// throttle counters.
+	CPUThrottleSourceFrequency = "frequency"
+)
+
+// A core counts as frequency-throttled when it was at least
+// freqThrottleBusyPct busy yet ran below freqThrottleRatio of its max clock.
+const (
+	freqThrottleBusyPct = 90.0
+	freqThrottleRatio   = 0.75
+)
+
+// CPUThrottleSnapshot reports whether the CPU throttled during the last
+// interval. Events is the number of throttle events in that interval; with
+// the frequency source it is the number of throttled cores and Count the
+// number of intervals each core spent throttled.
+type CPUThrottleSnapshot struct {
+	Throttling   bool
+	Source       string
+	Events       uint64
+	PackageCount uint64
+	Cores        []CPUCoreThrottle
+}
+
+type CPUCoreThrottle struct {
+	ID         int
+	Count      uint64
+	Throttling bool
+}
+
+// thermalThrottleCounts holds core_throttle_count per CPU and
+// package_throttle_count per package. PhysicalCores maps each CPU to its
+// physical core, since SMT siblings report the same core counter.
+type thermalThrottleCounts struct {
+	Cores         map[int]uint64
+	PhysicalCores map[int]string
+	Packages      map[string]uint64
+}
+
+type CPUThrottleSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	cpuDir   string
+	statPath string
+
+	lastCounts thermalThrottleCounts
+	lastStat   procStat
+	freqCounts map[int]uint64
+
+	snapshot CPUThrottleSnapshot
+}
+
+func NewCPUThrottleSampler(interval time.Duration, root Root) *CPUThrottleSampler {
+	s := &CPUThrottleSampler{
+		cpuDir:     root.sys("devices", "system", "cpu"),
+		statPath:   root.proc("stat"),
+		freqCounts: make(map[int]uint64),
+	}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *CPUThrottleSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if counts, ok := readThermalThrottle(s.cpuDir); ok {
+			if s.lastCounts.Cores != nil {
+				snapshot := thermalThrottleSnapshot(s.lastCounts, counts)
+				s.mu.Lock()
+				s.snapshot = snapshot
+				s.mu.Unlock()
+			}
+			s.lastCounts = counts
+			continue
+		}
+
+		stat, err := readProcStat(s.statPath)
+		if err != nil {
+			continue
+		}
+		freq, err := readCPUFreq(s.cpuDir)
+		if err != nil {
+			continue
+		}
+		if s.lastStat.Total.total() != 0 {
+			busy := cpuBusySnapshot(s.lastStat, stat)
+			snapshot := freqThrottleSnapshot(busy.Cores, freq.Cores, s.freqCounts)
+			s.mu.Lock()
+			s.snapshot = snapshot
+			s.mu.Unlock()
+		}
+		s.lastStat = stat
+	}
+}
+
+func (s *CPUThrottleSampler) Snapshot() CPUThrottleSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := s.snapshot
+	snapshot.Cores = append([]CPUCoreThrottle(nil), s.snapshot.Cores...)
+	return snapshot
+}
+
+func thermalThrottleSnapshot(prev thermalThrottleCounts, cur thermalThrottleCounts) CPUThrottleSnapshot {
+	snapshot := CPUThrottleSnapshot{Source: CPUThrottleSourceThermal}
+
+	counted := make(map[string]bool)
+	for id, count := range cur.Cores {
+		delta := counterDelta(prev.Cores[id], count)
+		if core := cur.PhysicalCores[id]; !counted[core] {
+			counted[core] = true
+			snapshot.Events += delta
+		}
+		snapshot.Cores = append(snapshot.Cores, CPUCoreThrottle{ID: id, Count: count, Throttling: delta > 0})
+	}
+	for pkg, count := range cur.Packages {
+		snapshot.Events += counterDelta(prev.Packages[pkg], count)
+		snapshot.PackageCount += count
+	}
+	snapshot.Throttling = snapshot.Events > 0
+
+	sort.Slice(snapshot.Cores, func(i, j int) bool {
+		return snapshot.Cores[i].ID < snapshot.Cores[j].ID
+	})
+
+	return snapshot
+}
+
+// freqThrottleSnapshot flags busy cores running below freqThrottleRatio of
+// their max clock, adding to each core's running count in counts.
+func freqThrottleSnapshot(busy []CPUCoreBusy, freq []CPUCoreFreq, counts map[int]uint64) CPUThrottleSnapshot {
+	snapshot := CPUThrottleSnapshot{Source: CPUThrottleSourceFrequency}
+
+	utilPct := make(map[int]float64, len(busy))
+	for _, core := range busy {
+		utilPct[core.ID] = core.UtilPct
+	}
+
+	for _, core := range freq {
+		throttling := core.MaxMHz > 0 && utilPct[core.ID] >= freqThrottleBusyPct && core.CurMHz < freqThrottleRatio*core.MaxMHz
+		if throttling {
+			counts[core.ID]++
+			snapshot.Events++
+		}
+		snapshot.Cores = append(snapshot.Cores, CPUCoreThrottle{ID: core.ID, Count: counts[core.ID], Throttling: throttling})
+	}
+	snapshot.Throttling = snapshot.Events > 0
+
+	return snapshot
+}
+
+// readThermalThrottle reads the Intel thermal_throttle counters. Package
+// counters are shared by every CPU of a package, so they are keyed by
+// physical_package_id; core counters are shared by SMT siblings, so each
+// CPU is mapped to its physical_package_id/core_id. ok is false when the
+// CPU has no such counters.
+func readThermalThrottle(cpuDir string) (thermalThrottleCounts, bool) {
+	paths, _ := filepath.Glob(filepath.Join(cpuDir, "cpu[0-9]*", "thermal_throttle", "core_throttle_count"))
+	if len(paths) == 0 {
+		return thermalThrottleCounts{}, false
+	}
+
+	counts := thermalThrottleCounts{
+		Cores:         make(map[int]uint64),
+		PhysicalCores: make(map[int]string),
+		Packages:      make(map[string]uint64),
+	}
+	for _, path := range paths {
+		dir := filepath.Dir(filepath.Dir(path))
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		if err != nil {
+			continue
+		}
+
+		count, err := readUintFromFile(path)
+		if err != nil {
+			continue
+		}
+		counts.Cores[id] = count
+
+		pkg, err := readTrimmedFile(filepath.Join(dir, "topology", "physical_package_id"))
+		if err != nil {
+			pkg = "0"
+		}
+		if core, err := readTrimmedFile(filepath.Join(dir, "topology", "core_id")); err == nil {
+			counts.PhysicalCores[id] = pkg + "/" + core
+		} else {
+			counts.PhysicalCores[id] = "cpu" + strconv.Itoa(id)
+		}
+		if _, ok := counts.Packages[pkg]; ok {
+			continue
+		}
+		if count, err := readUintFromFile(filepath.Join(dir, "thermal_throttle", "package_throttle_count")); err == nil {
+			counts.Packages[pkg] = count
+		}
+	}
+
+	return counts, true
+}
@@ -0,0 +1,91 @@
+package sensors
+
+import "testing"
+
+func TestReadThermalThrottle(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	for cpu, count := range map[string]string{"cpu0": "12", "cpu1": "3", "cpu8": "0"} {
+		writeFixture(t, root.Sys, "devices/system/cpu/"+cpu+"/thermal_throttle/core_throttle_count", count+"\n")
+		writeFixture(t, root.Sys, "devices/system/cpu/"+cpu+"/thermal_throttle/package_throttle_count", "40\n")
+		writeFixture(t, root.Sys, "devices/system/cpu/"+cpu+"/topology/physical_package_id", "0\n")
+	}
+	// cpu8 is cpu0's SMT sibling.
+	for cpu, core := range map[string]string{"cpu0": "0", "cpu1": "1", "cpu8": "0"} {
+		writeFixture(t, root.Sys, "devices/system/cpu/"+cpu+"/topology/core_id", core+"\n")
+	}
+
+	counts, ok := readThermalThrottle(root.sys("devices", "system", "cpu"))
+	if !ok {
+		t.Fatal("expected thermal_throttle counters")
+	}
+	if len(counts.Cores) != 3 || counts.Cores[0] != 12 || counts.Cores[8] != 0 {
+		t.Fatalf("core counts got %+v", counts.Cores)
+	}
+	if len(counts.Packages) != 1 || counts.Packages["0"] != 40 {
+		t.Fatalf("package counts got %+v", counts.Packages)
+	}
+	if counts.PhysicalCores[0] != "0/0" || counts.PhysicalCores[8] != "0/0" || counts.PhysicalCores[1] != "0/1" {
+		t.Fatalf("physical cores got %+v", counts.PhysicalCores)
+	}
+
+	if _, ok := readThermalThrottle(t.TempDir()); ok {
+		t.Fatal("expected no counters on a CPU without thermal_throttle")
+	}
+}
+
+func TestThermalThrottleSnapshot(t *testing.T) {
+	cores := map[int]string{0: "0/0", 1: "0/1"}
+	prev := thermalThrottleCounts{Cores: map[int]uint64{0: 10, 1: 3}, PhysicalCores: cores, Packages: map[string]uint64{"0": 40}}
+	cur := thermalThrottleCounts{Cores: map[int]uint64{0: 12, 1: 3}, PhysicalCores: cores, Packages: map[string]uint64{"0": 41}}
+
+	snapshot := thermalThrottleSnapshot(prev, cur)
+	if !snapshot.Throttling || snapshot.Source != CPUThrottleSourceThermal || snapshot.Events != 3 || snapshot.PackageCount != 41 {
+		t.Fatalf("snapshot got %+v", snapshot)
+	}
+	want := []CPUCoreThrottle{{ID: 0, Count: 12, Throttling: true}, {ID: 1, Count: 3}}
+	if len(snapshot.Cores) != len(want) || snapshot.Cores[0] != want[0] || snapshot.Cores[1] != want[1] {
+		t.Fatalf("cores got %+v, want %+v", snapshot.Cores, want)
+	}
+
+	if idle := thermalThrottleSnapshot(cur, cur); idle.Throttling || idle.Events != 0 {
+		t.Fatalf("unchanged counters should not throttle, got %+v", idle)
+	}
+}
+
+func TestThermalThrottleSnapshotCountsSMTSiblingsOnce(t *testing.T) {
+	// cpu0 and cpu8 are SMT siblings of one physical core and report the
+	// same core counter.
+	cores := map[int]string{0: "0/0", 8: "0/0"}
+	prev := thermalThrottleCounts{Cores: map[int]uint64{0: 10, 8: 10}, PhysicalCores: cores, Packages: map[string]uint64{}}
+	cur := thermalThrottleCounts{Cores: map[int]uint64{0: 12, 8: 12}, PhysicalCores: cores, Packages: map[string]uint64{}}
+
+	snapshot := thermalThrottleSnapshot(prev, cur)
+	if snapshot.Events != 2 {
+		t.Fatalf("events got %d, want 2", snapshot.Events)
+	}
+	if len(snapshot.Cores) != 2 || !snapshot.Cores[0].Throttling || !snapshot.Cores[1].Throttling {
+		t.Fatalf("both siblings should be flagged, got %+v", snapshot.Cores)
+	}
+}
+
+func TestFreqThrottleSnapshot(t *testing.T) {
+	busy := []CPUCoreBusy{{ID: 0, UtilPct: 99}, {ID: 1, UtilPct: 99}, {ID: 2, UtilPct: 5}}
+	freq := []CPUCoreFreq{
+		{ID: 0, CurMHz: 2800, MaxMHz: 5700},
+		{ID: 1, CurMHz: 5100, MaxMHz: 5700},
+		// Idle cores clock down without being throttled.
+		{ID: 2, CurMHz: 600, MaxMHz: 5700},
+	}
+	counts := map[int]uint64{0: 4}
+
+	snapshot := freqThrottleSnapshot(busy, freq, counts)
+	if !snapshot.Throttling || snapshot.Source != CPUThrottleSourceFrequency || snapshot.Events != 1 {
+		t.Fatalf("snapshot got %+v", snapshot)
+	}
+	want := []CPUCoreThrottle{{ID: 0, Count: 5, Throttling: true}, {ID: 1}, {ID: 2}}
+	for i := range want {
+		if snapshot.Cores[i] != want[i] {
+			t.Fatalf("core %d got %+v, want %+v", i, snapshot.Cores[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const diskSectorBytes = 512
+
+// virtualDiskPrefixes are skipped unless the sampler includes all devices.
+var virtualDiskPrefixes = []string{"loop", "ram", "zram"}
+
+type DiskIOSnapshot struct {
+	Disks []DiskIO
+}
+
+// DiskIO is the throughput of one block device over the last interval.
+// UtilPct is the share of the interval the device had I/O in flight, and
+// the await values the average time a completed request took.
+type DiskIO struct {
+	Name         string
+	ReadMBps     float64
+	WriteMBps    float64
+	ReadIOPS     float64
+	WriteIOPS    float64
+	UtilPct      float64
+	ReadAwaitMs  float64
+	WriteAwaitMs float64
+}
+
+type diskStat struct {
+	Reads          uint64
+	SectorsRead    uint64
+	ReadMs         uint64
+	Writes         uint64
+	SectorsWritten uint64
+	WriteMs        uint64
+	IOMs           uint64
+}
+
+type DiskIOSampler struct {
+	lifecycle
+
+	mu         sync.RWMutex
+	statsPath  string
+	blockDir   string
+	includeAll bool
+	last       map[string]diskStat
+	lastAt     time.Time
+	snapshot   DiskIOSnapshot
+}
+
+// NewDiskIOSampler samples /proc/diskstats. By default only whole disks are
+// reported; includeAll also reports partitions and loop/ram/zram devices.
+func NewDiskIOSampler(interval time.Duration, root Root, includeAll bool) *DiskIOSampler {
+	s := &DiskIOSampler{
+		statsPath:  root.proc("diskstats"),
+		blockDir:   root.sys("block"),
+		includeAll: includeAll,
+	}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *DiskIOSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats, err := readDiskStats(s.statsPath)
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+
+		// A late tick covers more than interval, so rates use the time
+		// actually elapsed since the last read.
+		s.mu.Lock()
+		if s.last != nil {
+			s.snapshot = diskIOSnapshot(s.last, stats, now.Sub(s.lastAt), s.keepDisk)
+		}
+		s.last = stats
+		s.lastAt = now
+		s.mu.Unlock()
+	}
+}
+
+func (s *DiskIOSampler) Snapshot() DiskIOSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return DiskIOSnapshot{Disks: append([]DiskIO(nil), s.snapshot.Disks...)}
+}
+
+func (s *DiskIOSampler) keepDisk(name string) bool {
+	if s.includeAll {
+		return true
+	}
+
+	return isWholeDisk(s.blockDir, name)
+}
+
+// isWholeDisk reports whether name is a physical or device-mapper disk
+// rather than a partition or a loop/ram device. Only whole disks appear
+// directly under /sys/block.
+func isWholeDisk(blockDir string, name string) bool {
+	for _, prefix := range virtualDiskPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+
+	// Names such as cciss/c0d0 appear as cciss!c0d0 in sysfs.
+	_, err := os.Stat(filepath.Join(blockDir, strings.ReplaceAll(name, "/", "!")))
+	return err == nil
+}
+
+func diskIOSnapshot(prev map[string]diskStat, cur map[string]diskStat, elapsed time.Duration, keep func(string) bool) DiskIOSnapshot {
+	var snapshot DiskIOSnapshot
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return snapshot
+	}
+
+	for name, c := range cur {
+		p, ok := prev[name]
+		if !ok || !keep(name) {
+			continue
+		}
+
+		reads := counterDelta(p.Reads, c.Reads)
+		writes := counterDelta(p.Writes, c.Writes)
+		disk := DiskIO{
+			Name:      name,
+			ReadMBps:  float64(counterDelta(p.SectorsRead, c.SectorsRead)*diskSectorBytes) / 1_000_000.0 / seconds,
+			WriteMBps: float64(counterDelta(p.SectorsWritten, c.SectorsWritten)*diskSectorBytes) / 1_000_000.0 / seconds,
+			ReadIOPS:  float64(reads) / seconds,
+			WriteIOPS: float64(writes) / seconds,
+			UtilPct:   min(100.0, 100.0*float64(counterDelta(p.IOMs, c.IOMs))/float64(elapsed.Milliseconds())),
+		}
+		if reads > 0 {
+			disk.ReadAwaitMs = float64(counterDelta(p.ReadMs, c.ReadMs)) / float64(reads)
+		}
+		if writes > 0 {
+			disk.WriteAwaitMs = float64(counterDelta(p.WriteMs, c.WriteMs)) / float64(writes)
+		}
+
+		snapshot.Disks = append(snapshot.Disks, disk)
+	}
+
+	sort.Slice(snapshot.Disks, func(i, j int) bool {
+		return naturalLess(snapshot.Disks[i].Name, snapshot.Disks[j].Name)
+	})
+
+	return snapshot
+}
+
+func readDiskStats(path string) (map[string]diskStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	stats := make(map[string]diskStat)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// major minor name reads merged sectors ms writes merged sectors ms
+		// in_flight io_ms weighted_ms [discard and flush fields...]
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			continue
+		}
+
+		var values [11]uint64
+		for i := range values {
+			values[i], _ = strconv.ParseUint(fields[3+i], 10, 64)
+		}
+
+		stats[fields[2]] = diskStat{
+			Reads:          values[0],
+			SectorsRead:    values[2],
+			ReadMs:         values[3],
+			Writes:         values[4],
+			SectorsWritten: values[6],
+			WriteMs:        values[7],
+			IOMs:           values[9],
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,56 @@
+package sensors
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDiskIOSnapshot(t *testing.T) {
+	root := Root{Proc: t.TempDir(), Sys: t.TempDir()}
+	writeFixture(t, root.Proc, "diskstats.0", `   7       0 loop0 120 0 2400 10 0 0 0 0 0 10 10 0 0 0 0
+ 259       0 nvme0n1 1000 0 20000 500 2000 0 40000 1000 0 800 1500 0 0 0 0 0 0
+ 259       1 nvme0n1p1 900 0 18000 450 1900 0 38000 950 0 700 1400 0 0 0 0
+ 253       0 dm-0 10 0 80 5 10 0 80 5 0 10 10
+`)
+	writeFixture(t, root.Proc, "diskstats.1", `   7       0 loop0 220 0 4400 20 0 0 0 0 0 20 20 0 0 0 0
+ 259       0 nvme0n1 1200 0 224800 700 2100 0 60000 1300 2 1300 2000 0 0 0 0 0 0
+ 259       1 nvme0n1p1 1100 0 222800 650 2000 0 58000 1250 2 1200 1900 0 0 0 0
+ 253       0 dm-0 10 0 80 5 10 0 80 5 0 10 10
+`)
+	writeFixture(t, root.Sys, "block/nvme0n1/stat", "")
+	writeFixture(t, root.Sys, "block/dm-0/stat", "")
+	writeFixture(t, root.Sys, "block/loop0/stat", "")
+
+	prev, err := readDiskStats(root.proc("diskstats.0"))
+	if err != nil {
+		t.Fatalf("readDiskStats error: %v", err)
+	}
+	cur, err := readDiskStats(root.proc("diskstats.1"))
+	if err != nil {
+		t.Fatalf("readDiskStats error: %v", err)
+	}
+
+	s := &DiskIOSampler{blockDir: root.sys("block")}
+	snapshot := diskIOSnapshot(prev, cur, 2*time.Second, s.keepDisk)
+
+	if len(snapshot.Disks) != 2 || snapshot.Disks[0].Name != "dm-0" || snapshot.Disks[1].Name != "nvme0n1" {
+		t.Fatalf("expected dm-0 and nvme0n1 only, got %+v", snapshot.Disks)
+	}
+
+	nvme := snapshot.Disks[1]
+	if math.Abs(nvme.ReadMBps-52.4288) > 1e-9 || math.Abs(nvme.WriteMBps-5.12) > 1e-9 {
+		t.Fatalf("throughput got read=%v write=%v", nvme.ReadMBps, nvme.WriteMBps)
+	}
+	if nvme.ReadIOPS != 100 || nvme.WriteIOPS != 50 {
+		t.Fatalf("IOPS got read=%v write=%v", nvme.ReadIOPS, nvme.WriteIOPS)
+	}
+	if math.Abs(nvme.UtilPct-25) > 1e-9 || nvme.ReadAwaitMs != 1 || nvme.WriteAwaitMs != 3 {
+		t.Fatalf("util/await got %+v", nvme)
+	}
+
+	s.includeAll = true
+	if all := diskIOSnapshot(prev, cur, 2*time.Second, s.keepDisk); len(all.Disks) != 4 {
+		t.Fatalf("includeAll expected 4 devices, got %+v", all.Disks)
+	}
+}
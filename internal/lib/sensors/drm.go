@@ -0,0 +1,154 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// drmCardPattern matches DRM card nodes (card0, card1) but not their
+// connectors (card1-DP-1).
+var drmCardPattern = regexp.MustCompile(`^card\d+$`)
+
+var pciAddrPattern = regexp.MustCompile(`^[0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-9a-f]$`)
+
+// GPUReadings flags the optional readings a GPU sampler found for a card,
+// so a reading of 0 can be told apart from one the driver does not expose.
+type GPUReadings uint16
+
+const (
+	GPUUtil GPUReadings = 1 << iota
+	GPUEdgeTemp
+	GPUHotspotTemp
+	GPUMemTemp
+	GPUPower
+	GPUGraphicsClock
+	GPUMemClock
+	GPUFan
+)
+
+// Has reports whether every reading in want is present.
+func (r GPUReadings) Has(want GPUReadings) bool {
+	return r&want == want
+}
+
+// record returns a func that passes a (value, ok) lookup through, flagging
+// reading as present when ok, e.g.
+//
+//	gpu.EdgeC = gpu.Readings.record(GPUEdgeTemp)(chip.lookup("temp", "edge"))
+func (r *GPUReadings) record(reading GPUReadings) func(v float64, ok bool) float64 {
+	return func(v float64, ok bool) float64 {
+		if ok {
+			*r |= reading
+		}
+		return v
+	}
+}
+
+// drmCard is a GPU exposed under /sys/class/drm.
+type drmCard struct {
+	Name   string
+	Driver string
+	// PCIAddr identifies the card across samplers and hwmon chips, e.g.
+	// 0000:03:00.0. It falls back to Name for non-PCI devices.
+	PCIAddr   string
+	DeviceDir string
+}
+
+// detectDRMCards lists every DRM card, ordered by PCI address so the order
+// does not depend on which driver probed first.
+func detectDRMCards(root Root) []drmCard {
+	dirs, err := filepath.Glob(root.sys("class", "drm", "card*"))
+	if err != nil {
+		return nil
+	}
+
+	var cards []drmCard
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+		if !drmCardPattern.MatchString(name) {
+			continue
+		}
+
+		deviceDir := filepath.Join(dir, "device")
+		if _, err := os.Stat(deviceDir); err != nil {
+			continue
+		}
+
+		addr := pciSlotName(deviceDir)
+		if addr == "" {
+			addr = name
+		}
+		cards = append(cards, drmCard{
+			Name:      name,
+			Driver:    readUevent(deviceDir)["DRIVER"],
+			PCIAddr:   addr,
+			DeviceDir: deviceDir,
+		})
+	}
+
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].PCIAddr != cards[j].PCIAddr {
+			return cards[i].PCIAddr < cards[j].PCIAddr
+		}
+		return naturalLess(cards[i].Name, cards[j].Name)
+	})
+
+	return cards
+}
+
+// pciSlotName returns the PCI address of a sysfs device directory, read from
+// its uevent or, failing that, from where the device symlink points.
+func pciSlotName(deviceDir string) string {
+	if addr, ok := readUevent(deviceDir)["PCI_SLOT_NAME"]; ok {
+		return strings.ToLower(addr)
+	}
+
+	resolved, err := filepath.EvalSymlinks(deviceDir)
+	if err != nil {
+		return ""
+	}
+	if base := filepath.Base(resolved); pciAddrPattern.MatchString(base) {
+		return base
+	}
+
+	return ""
+}
+
+// drmCardName returns the DRM card backed by a sysfs device directory, e.g.
+// card1, or "" when the device has none.
+func drmCardName(deviceDir string) string {
+	entries, err := os.ReadDir(filepath.Join(deviceDir, "drm"))
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		if drmCardPattern.MatchString(entry.Name()) {
+			return entry.Name()
+		}
+	}
+
+	return ""
+}
+
+// readUevent parses the KEY=value lines of a sysfs device's uevent file.
+func readUevent(deviceDir string) map[string]string {
+	values := make(map[string]string)
+	uevent, err := readTrimmedFile(filepath.Join(deviceDir, "uevent"))
+	if err != nil {
+		return values
+	}
+
+	for _, line := range strings.Split(uevent, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return values
+}
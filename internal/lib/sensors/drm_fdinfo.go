@@ -0,0 +1,220 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// drmEngineClasses maps the xe engine class names used in drm-cycles-* keys
+// onto the names i915 uses in drm-engine-* keys.
+var drmEngineClasses = map[string]string{
+	"rcs":  "render",
+	"bcs":  "copy",
+	"vcs":  "video",
+	"vecs": "video-enhance",
+	"ccs":  "compute",
+}
+
+// drmClientKey identifies one DRM client. Several fds, possibly in several
+// processes, can share a client, so usage is deduplicated on this key.
+type drmClientKey struct {
+	PDev string
+	ID   string
+}
+
+// drmClient is the usage a DRM client reports in /proc/<pid>/fdinfo/<fd>,
+// per the kernel's drm-usage-stats format.
+type drmClient struct {
+	Driver  string
+	PDev    string
+	ID      string
+	Engines map[string]drmEngineUsage
+	// VRAMBytes is the device-local memory the client holds, from
+	// drm-resident-* or the older drm-memory-* keys.
+	VRAMBytes uint64
+}
+
+// drmEngineUsage holds an engine's counters: i915 reports busy time in ns,
+// xe reports busy cycles against a total cycle count.
+type drmEngineUsage struct {
+	BusyNs      uint64
+	Cycles      uint64
+	TotalCycles uint64
+	Capacity    int
+}
+
+func (c drmClient) key() drmClientKey {
+	return drmClientKey{PDev: c.PDev, ID: c.ID}
+}
+
+// readDRMClients walks every process's open /dev/dri fds and returns the
+// DRM clients they belong to.
+func readDRMClients(root Root) map[drmClientKey]drmClient {
+	clients := make(map[drmClientKey]drmClient)
+	for _, pidClients := range readDRMClientsByPID(root) {
+		for key, client := range pidClients {
+			clients[key] = client
+		}
+	}
+
+	return clients
+}
+
+// readDRMClientsByPID is readDRMClients grouped by the process holding the
+// fds. A client shared between processes is listed under each of them.
+func readDRMClientsByPID(root Root) map[int]map[drmClientKey]drmClient {
+	byPID := make(map[int]map[drmClientKey]drmClient)
+
+	fdDirs, err := filepath.Glob(root.proc("[0-9]*", "fd"))
+	if err != nil {
+		return byPID
+	}
+
+	for _, fdDir := range fdDirs {
+		pid, err := strconv.Atoi(filepath.Base(filepath.Dir(fdDir)))
+		if err != nil {
+			continue
+		}
+		entries, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			target, err := os.Readlink(filepath.Join(fdDir, entry.Name()))
+			if err != nil || !strings.HasPrefix(target, "/dev/dri/") {
+				continue
+			}
+
+			raw, err := os.ReadFile(filepath.Join(filepath.Dir(fdDir), "fdinfo", entry.Name()))
+			if err != nil {
+				continue
+			}
+
+			client, ok := parseDRMFdinfo(string(raw))
+			if !ok {
+				continue
+			}
+			if byPID[pid] == nil {
+				byPID[pid] = make(map[drmClientKey]drmClient)
+			}
+			byPID[pid][client.key()] = client
+		}
+	}
+
+	return byPID
+}
+
+func parseDRMFdinfo(content string) (drmClient, bool) {
+	client := drmClient{Engines: make(map[string]drmEngineUsage)}
+
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "drm-driver":
+			client.Driver = value
+			continue
+		case "drm-pdev":
+			client.PDev = strings.ToLower(value)
+			continue
+		case "drm-client-id":
+			client.ID = value
+			continue
+		}
+		if isDRMVRAMKey(key) {
+			client.VRAMBytes = max(client.VRAMBytes, parseDRMMemory(value))
+			continue
+		}
+
+		// Order matters: drm-engine-capacity-* also starts with drm-engine-.
+		var field *uint64
+		var name string
+		var capacity uint64
+		usage := drmEngineUsage{}
+		switch {
+		case strings.HasPrefix(key, "drm-engine-capacity-"):
+			name, field = strings.TrimPrefix(key, "drm-engine-capacity-"), &capacity
+		case strings.HasPrefix(key, "drm-engine-"):
+			name, field = strings.TrimPrefix(key, "drm-engine-"), &usage.BusyNs
+		case strings.HasPrefix(key, "drm-total-cycles-"):
+			name, field = strings.TrimPrefix(key, "drm-total-cycles-"), &usage.TotalCycles
+		case strings.HasPrefix(key, "drm-cycles-"):
+			name, field = strings.TrimPrefix(key, "drm-cycles-"), &usage.Cycles
+		default:
+			continue
+		}
+
+		number, err := strconv.ParseUint(strings.TrimSuffix(value, " ns"), 10, 64)
+		if err != nil {
+			continue
+		}
+		*field = number
+
+		if class, ok := drmEngineClasses[name]; ok {
+			name = class
+		}
+		client.Engines[name] = mergeEngineUsage(client.Engines[name], usage, int(capacity))
+	}
+
+	if client.Driver == "" || client.ID == "" {
+		return drmClient{}, false
+	}
+
+	return client, true
+}
+
+func mergeEngineUsage(cur drmEngineUsage, update drmEngineUsage, capacity int) drmEngineUsage {
+	cur.BusyNs += update.BusyNs
+	cur.Cycles += update.Cycles
+	cur.TotalCycles += update.TotalCycles
+	if capacity > 0 {
+		cur.Capacity = capacity
+	}
+
+	return cur
+}
+
+// isDRMVRAMKey matches the device-local memory regions drivers report:
+// vram (amdgpu), vram0 (xe) and local0 (i915).
+func isDRMVRAMKey(key string) bool {
+	var region string
+	switch {
+	case strings.HasPrefix(key, "drm-resident-"):
+		region = strings.TrimPrefix(key, "drm-resident-")
+	case strings.HasPrefix(key, "drm-memory-"):
+		region = strings.TrimPrefix(key, "drm-memory-")
+	default:
+		return false
+	}
+
+	return strings.HasPrefix(region, "vram") || strings.HasPrefix(region, "local")
+}
+
+// parseDRMMemory parses a drm-usage-stats memory value such as "524288 KiB".
+func parseDRMMemory(value string) uint64 {
+	number, unit, _ := strings.Cut(value, " ")
+	n, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	switch unit {
+	case "KiB":
+		return n << 10
+	case "MiB":
+		return n << 20
+	case "GiB":
+		return n << 30
+	default:
+		return n
+	}
+}
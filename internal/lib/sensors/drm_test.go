@@ -0,0 +1,47 @@
+package sensors
+
+import "testing"
+
+func TestDetectDRMCards(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+
+	writeFixture(t, root.Sys, "class/drm/card0/device/uevent", "DRIVER=amdgpu\nPCI_SLOT_NAME=0000:7c:00.0\n")
+	writeFixture(t, root.Sys, "class/drm/card0/device/gpu_busy_percent", "3\n")
+	writeFixture(t, root.Sys, "class/drm/card0/device/mem_info_vram_used", "536870912\n")
+	writeFixture(t, root.Sys, "class/drm/card0/device/mem_info_vram_total", "2147483648\n")
+	writeFixture(t, root.Sys, "class/drm/card1/device/uevent", "DRIVER=amdgpu\nPCI_SLOT_NAME=0000:03:00.0\n")
+	writeFixture(t, root.Sys, "class/drm/card1/device/gpu_busy_percent", "98\n")
+	writeFixture(t, root.Sys, "class/drm/card1/device/mem_info_vram_total", "17163091968\n")
+	writeFixture(t, root.Sys, "class/drm/card1-DP-1/status", "connected\n")
+
+	cards := detectDRMCards(root)
+	if len(cards) != 2 {
+		t.Fatalf("detectDRMCards got %+v, want 2 cards", cards)
+	}
+	if cards[0].Name != "card1" || cards[0].PCIAddr != "0000:03:00.0" {
+		t.Fatalf("first card got %+v, want card1 at 0000:03:00.0", cards[0])
+	}
+	if cards[1].Name != "card0" || cards[1].PCIAddr != "0000:7c:00.0" {
+		t.Fatalf("second card got %+v, want card0 at 0000:7c:00.0", cards[1])
+	}
+
+	if busy := detectGPUBusyCards(root); len(busy) != 2 {
+		t.Fatalf("detectGPUBusyCards got %+v, want both cards", busy)
+	}
+	// card1 has no mem_info_vram_used, so its total must not be paired with
+	// card0's used.
+	vram := detectVRAMCards(root)
+	if len(vram) != 1 || vram[0].Name != "card0" {
+		t.Fatalf("detectVRAMCards got %+v, want only card0", vram)
+	}
+}
+
+func TestDetectDRMCardsFallsBackToCardName(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeFixture(t, root.Sys, "class/drm/card0/device/gpu_busy_percent", "10\n")
+
+	cards := detectDRMCards(root)
+	if len(cards) != 1 || cards[0].PCIAddr != "card0" {
+		t.Fatalf("detectDRMCards got %+v, want card0 keyed by name", cards)
+	}
+}
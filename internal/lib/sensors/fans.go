@@ -0,0 +1,98 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type FanSnapshot struct {
+	Fans []Fan
+}
+
+// Fan is one fanN channel of a hwmon chip (nct6775, it87, asus-ec, amdgpu,
+// ...). PWMPct is the duty cycle of the matching pwmN; HasPWM is false when
+// the chip does not expose one.
+type Fan struct {
+	Chip    string
+	PCIAddr string
+	Channel string
+	Label   string
+	RPM     float64
+	PWMPct  float64
+	HasPWM  bool
+}
+
+type FanSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	snapshot FanSnapshot
+	root     Root
+}
+
+func NewFanSampler(interval time.Duration, root Root) *FanSampler {
+	s := &FanSampler{root: root}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *FanSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		chips, err := readHwmonChips(s.root)
+		if err != nil {
+			continue
+		}
+
+		snapshot := fanSnapshot(chips)
+		s.mu.Lock()
+		s.snapshot = snapshot
+		s.mu.Unlock()
+	}
+}
+
+func (s *FanSampler) Snapshot() FanSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return FanSnapshot{Fans: append([]Fan(nil), s.snapshot.Fans...)}
+}
+
+func fanSnapshot(chips []hwmonChip) FanSnapshot {
+	var snapshot FanSnapshot
+	for _, chip := range chips {
+		for _, input := range chip.Inputs {
+			if input.Kind != "fan" {
+				continue
+			}
+
+			index := strconv.Itoa(input.Index)
+			pwm, hasPWM := readPWMPct(filepath.Join(chip.Dir, "pwm"+index))
+			snapshot.Fans = append(snapshot.Fans, Fan{
+				Chip:    chip.Name,
+				PCIAddr: chip.PCIAddr,
+				Channel: "fan" + index,
+				Label:   input.Label,
+				RPM:     input.Value,
+				PWMPct:  pwm,
+				HasPWM:  hasPWM,
+			})
+		}
+	}
+
+	return snapshot
+}
@@ -0,0 +1,33 @@
+package sensors
+
+import "testing"
+
+func TestFanSnapshot(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeHwmonFixture(t, root)
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/name", "nct6798\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/fan1_input", "0\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/fan2_input", "1120\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/fan2_label", "CPU Fan\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/pwm2", "255\n")
+
+	chips, err := readHwmonChips(root)
+	if err != nil {
+		t.Fatalf("readHwmonChips error: %v", err)
+	}
+	snapshot := fanSnapshot(chips)
+
+	want := []Fan{
+		{Chip: "nct6798", Channel: "fan1", Label: "fan1", RPM: 0},
+		{Chip: "nct6798", Channel: "fan2", Label: "CPU Fan", RPM: 1120, PWMPct: 100, HasPWM: true},
+		{Chip: "amdgpu", PCIAddr: "0000:03:00.0", Channel: "fan1", Label: "fan1", RPM: 1450, PWMPct: 40, HasPWM: true},
+	}
+	if len(snapshot.Fans) != len(want) {
+		t.Fatalf("fanSnapshot got %+v, want %+v", snapshot.Fans, want)
+	}
+	for i := range want {
+		if snapshot.Fans[i] != want[i] {
+			t.Fatalf("fan %d got %+v, want %+v", i, snapshot.Fans[i], want[i])
+		}
+	}
+}
@@ -60,7 +60,7 @@ func TestReadEnergy(t *testing.T) {
 	}
 }
 
-func TestReadVRAMSnapshot(t *testing.T) {
+func TestReadVRAM(t *testing.T) {
 	dir := t.TempDir()
 	usedPath := filepath.Join(dir, "used")
 	totalPath := filepath.Join(dir, "total")
@@ -72,9 +72,9 @@ func TestReadVRAMSnapshot(t *testing.T) {
 		t.Fatalf("write total file: %v", err)
 	}
 
-	snapshot, err := readVRAMSnapshot(usedPath, totalPath)
+	snapshot, err := readVRAM(usedPath, totalPath)
 	if err != nil {
-		t.Fatalf("readVRAMSnapshot error: %v", err)
+		t.Fatalf("readVRAM error: %v", err)
 	}
 
 	if math.Abs(snapshot.UsedGB-2.0) > 1e-9 {
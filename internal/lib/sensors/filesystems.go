@@ -0,0 +1,268 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultFSExcludeTypes are skipped when a filter sets no ExcludeTypes.
+// tmpfs, overlay and squashfs are memory-backed or read-only images whose
+// usage is not actionable. Network and FUSE mounts are skipped because
+// statfs() on a stalled server blocks the sampler, and autofs because
+// statfs() would trigger the automount.
+var DefaultFSExcludeTypes = []string{"tmpfs", "overlay", "squashfs", "autofs", "nfs*", "cifs", "smb3", "fuse.*"}
+
+// FSFilter selects which mounts are reported. Include lists, when set, are
+// allow-lists; exclude lists always win. Type and mount patterns use
+// path.Match syntax, e.g. fuse.* or /run/media/*. A nil ExcludeTypes means
+// DefaultFSExcludeTypes; an empty, non-nil one excludes nothing.
+type FSFilter struct {
+	IncludeTypes  []string
+	ExcludeTypes  []string
+	IncludeMounts []string
+	ExcludeMounts []string
+}
+
+func (f FSFilter) keep(m mountInfo) bool {
+	if len(f.IncludeTypes) > 0 && !matchAny(f.IncludeTypes, m.FSType) {
+		return false
+	}
+	if matchAny(f.ExcludeTypes, m.FSType) {
+		return false
+	}
+	if len(f.IncludeMounts) > 0 && !matchAny(f.IncludeMounts, m.MountPoint) {
+		return false
+	}
+
+	return !matchAny(f.ExcludeMounts, m.MountPoint)
+}
+
+// includes reports whether an IncludeTypes or IncludeMounts pattern names m.
+func (f FSFilter) includes(m mountInfo) bool {
+	return matchAny(f.IncludeTypes, m.FSType) || matchAny(f.IncludeMounts, m.MountPoint)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type FilesystemSnapshot struct {
+	Filesystems []Filesystem
+}
+
+// Filesystem is the usage of one mount. FreeGiB is what unprivileged users
+// can still write and UsedPct is relative to used+free, as df reports it,
+// so a full disk reads 100% even with root-reserved blocks left.
+type Filesystem struct {
+	MountPoint    string
+	Device        string
+	FSType        string
+	TotalGiB      float64
+	UsedGiB       float64
+	FreeGiB       float64
+	UsedPct       float64
+	InodesTotal   uint64
+	InodesUsed    uint64
+	InodesUsedPct float64
+}
+
+type mountInfo struct {
+	DevID      string
+	MountPoint string
+	FSType     string
+	Source     string
+}
+
+type FilesystemSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	snapshot FilesystemSnapshot
+	path     string
+	filter   FSFilter
+}
+
+func NewFilesystemSampler(interval time.Duration, root Root, filter FSFilter) *FilesystemSampler {
+	if filter.ExcludeTypes == nil {
+		filter.ExcludeTypes = DefaultFSExcludeTypes
+	}
+
+	s := &FilesystemSampler{path: root.proc("self", "mountinfo"), filter: filter}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *FilesystemSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mounts, err := readMountInfo(s.path)
+		if err != nil {
+			continue
+		}
+		snapshot := filesystemSnapshot(mounts, s.filter)
+
+		s.mu.Lock()
+		s.snapshot = snapshot
+		s.mu.Unlock()
+	}
+}
+
+func (s *FilesystemSampler) Snapshot() FilesystemSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return FilesystemSnapshot{Filesystems: append([]Filesystem(nil), s.snapshot.Filesystems...)}
+}
+
+// filesystemSnapshot statfs()'s every kept mount. A device mounted more
+// than once (bind mounts, btrfs subvolumes) is reported at its first mount
+// only, and pseudo filesystems with no blocks are dropped.
+//
+// The bare autofs entry is left to the type filter, which skips it by
+// default so the sampler does not trigger the mount. A filesystem mounted
+// on top of an autofs trigger (x-systemd.automount) is only read when an
+// include pattern names it, since reading it every tick keeps the
+// automount from expiring.
+func filesystemSnapshot(mounts []mountInfo, filter FSFilter) FilesystemSnapshot {
+	automounts := make(map[string]bool)
+	for _, m := range mounts {
+		if m.FSType == "autofs" {
+			automounts[m.MountPoint] = true
+		}
+	}
+
+	var snapshot FilesystemSnapshot
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		if seen[m.DevID] || !filter.keep(m) {
+			continue
+		}
+		if m.FSType != "autofs" && automounts[m.MountPoint] && !filter.includes(m) {
+			continue
+		}
+
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(m.MountPoint, &st); err != nil || st.Blocks == 0 {
+			continue
+		}
+
+		seen[m.DevID] = true
+		fs := filesystemUsage(st)
+		fs.MountPoint = m.MountPoint
+		fs.Device = m.Source
+		fs.FSType = m.FSType
+		snapshot.Filesystems = append(snapshot.Filesystems, fs)
+	}
+
+	return snapshot
+}
+
+func filesystemUsage(st syscall.Statfs_t) Filesystem {
+	blockSize := float64(st.Bsize)
+	fs := Filesystem{
+		TotalGiB:    float64(st.Blocks) * blockSize / bytesPerGiB,
+		UsedGiB:     float64(st.Blocks-st.Bfree) * blockSize / bytesPerGiB,
+		FreeGiB:     float64(st.Bavail) * blockSize / bytesPerGiB,
+		InodesTotal: st.Files,
+	}
+	if usable := fs.UsedGiB + fs.FreeGiB; usable > 0 {
+		fs.UsedPct = 100.0 * fs.UsedGiB / usable
+	}
+	// Filesystems without fixed inode tables (btrfs, vfat) report 0.
+	if st.Files > 0 {
+		fs.InodesUsed = st.Files - st.Ffree
+		fs.InodesUsedPct = 100.0 * float64(fs.InodesUsed) / float64(st.Files)
+	}
+
+	return fs
+}
+
+// readMountInfo parses /proc/self/mountinfo:
+//
+//	36 35 98:0 /mnt1 /mnt/parent rw,noatime master:1 - ext3 /dev/root rw
+//
+// The optional fields before "-" vary in number, so fstype and source are
+// found relative to the separator.
+func readMountInfo(path string) ([]mountInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	var mounts []mountInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := slices.Index(fields, "-")
+		if sep < 6 || len(fields) < sep+3 {
+			continue
+		}
+
+		mounts = append(mounts, mountInfo{
+			DevID:      fields[2],
+			MountPoint: unescapeMountField(fields[4]),
+			FSType:     fields[sep+1],
+			Source:     unescapeMountField(fields[sep+2]),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return mounts, nil
+}
+
+// unescapeMountField decodes the octal escapes (\040 for space) the kernel
+// uses for whitespace and backslashes in mountinfo.
+func unescapeMountField(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
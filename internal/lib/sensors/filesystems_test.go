@@ -0,0 +1,123 @@
+package sensors
+
+import (
+	"math"
+	"syscall"
+	"testing"
+)
+
+func TestReadMountInfo(t *testing.T) {
+	root := Root{Proc: t.TempDir()}
+	writeFixture(t, root.Proc, "self/mountinfo", ""+
+		"26 1 259:2 / / rw,relatime shared:1 - ext4 /dev/nvme0n1p2 rw\n"+
+		"27 26 0:22 / /proc rw,nosuid - proc proc rw\n"+
+		"40 26 259:3 / /mnt/Steam\\040Library rw,relatime shared:20 master:3 - btrfs /dev/nvme1n1p1 rw\n"+
+		"malformed line\n")
+
+	mounts, err := readMountInfo(root.proc("self", "mountinfo"))
+	if err != nil {
+		t.Fatalf("readMountInfo error: %v", err)
+	}
+	if len(mounts) != 3 {
+		t.Fatalf("expected 3 mounts, got %+v", mounts)
+	}
+
+	want := mountInfo{DevID: "259:3", MountPoint: "/mnt/Steam Library", FSType: "btrfs", Source: "/dev/nvme1n1p1"}
+	if mounts[2] != want {
+		t.Fatalf("mount got %+v, want %+v", mounts[2], want)
+	}
+}
+
+func TestFSFilterKeep(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter FSFilter
+		mount  mountInfo
+		want   bool
+	}{
+		{name: "default excludes tmpfs", filter: FSFilter{ExcludeTypes: DefaultFSExcludeTypes}, mount: mountInfo{FSType: "tmpfs", MountPoint: "/tmp"}, want: false},
+		{name: "default excludes nfs4", filter: FSFilter{ExcludeTypes: DefaultFSExcludeTypes}, mount: mountInfo{FSType: "nfs4", MountPoint: "/mnt/nas"}, want: false},
+		{name: "default excludes fuse", filter: FSFilter{ExcludeTypes: DefaultFSExcludeTypes}, mount: mountInfo{FSType: "fuse.sshfs", MountPoint: "/mnt/remote"}, want: false},
+		{name: "default excludes autofs", filter: FSFilter{ExcludeTypes: DefaultFSExcludeTypes}, mount: mountInfo{FSType: "autofs", MountPoint: "/mnt/usb"}, want: false},
+		{name: "default keeps ext4", filter: FSFilter{ExcludeTypes: DefaultFSExcludeTypes}, mount: mountInfo{FSType: "ext4", MountPoint: "/"}, want: true},
+		{name: "include type glob", filter: FSFilter{IncludeTypes: []string{"fuse.*"}, ExcludeTypes: []string{}}, mount: mountInfo{FSType: "fuse.sshfs", MountPoint: "/mnt/remote"}, want: true},
+		{name: "include types", filter: FSFilter{IncludeTypes: []string{"btrfs"}}, mount: mountInfo{FSType: "ext4", MountPoint: "/"}, want: false},
+		{name: "include mount glob", filter: FSFilter{IncludeMounts: []string{"/run/media/*"}}, mount: mountInfo{FSType: "exfat", MountPoint: "/run/media/sd"}, want: true},
+		{name: "exclude mount wins", filter: FSFilter{IncludeTypes: []string{"vfat"}, ExcludeMounts: []string{"/boot/efi"}}, mount: mountInfo{FSType: "vfat", MountPoint: "/boot/efi"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.keep(tt.mount); got != tt.want {
+				t.Fatalf("keep(%+v)=%v, want %v", tt.mount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilesystemUsage(t *testing.T) {
+	// 100 GiB of 4 KiB blocks, 40 GiB free of which 35 GiB is available
+	// to unprivileged users.
+	const gib = 1 << 30 / 4096
+	st := syscall.Statfs_t{Bsize: 4096, Blocks: 100 * gib, Bfree: 40 * gib, Bavail: 35 * gib, Files: 1000, Ffree: 750}
+
+	fs := filesystemUsage(st)
+	if fs.TotalGiB != 100 || fs.UsedGiB != 60 || fs.FreeGiB != 35 {
+		t.Fatalf("sizes got %+v", fs)
+	}
+	if math.Abs(fs.UsedPct-100*60.0/95.0) > 1e-9 {
+		t.Fatalf("UsedPct got %v", fs.UsedPct)
+	}
+	if fs.InodesTotal != 1000 || fs.InodesUsed != 250 || fs.InodesUsedPct != 25 {
+		t.Fatalf("inodes got %+v", fs)
+	}
+}
+
+func TestFilesystemSnapshotDeduplicatesDevices(t *testing.T) {
+	dir := t.TempDir()
+	mounts := []mountInfo{
+		{DevID: "259:2", MountPoint: dir, FSType: "ext4", Source: "/dev/nvme0n1p2"},
+		{DevID: "259:2", MountPoint: dir, FSType: "ext4", Source: "/dev/nvme0n1p2"},
+		{DevID: "0:40", MountPoint: dir, FSType: "tmpfs", Source: "tmpfs"},
+		{DevID: "0:41", MountPoint: dir + "/missing", FSType: "ext4", Source: "/dev/sdz1"},
+	}
+
+	snapshot := filesystemSnapshot(mounts, FSFilter{ExcludeTypes: DefaultFSExcludeTypes})
+	if len(snapshot.Filesystems) != 1 {
+		t.Fatalf("expected 1 filesystem, got %+v", snapshot.Filesystems)
+	}
+	if fs := snapshot.Filesystems[0]; fs.MountPoint != dir || fs.Device != "/dev/nvme0n1p2" || fs.TotalGiB <= 0 {
+		t.Fatalf("filesystem got %+v", fs)
+	}
+}
+
+func TestFilesystemSnapshotAutomounts(t *testing.T) {
+	// An x-systemd.automount data drive: the autofs trigger and the real
+	// filesystem mounted on top of it share the mountpoint.
+	dir := t.TempDir()
+	root := Root{Proc: t.TempDir()}
+	writeFixture(t, root.Proc, "self/mountinfo", ""+
+		"50 26 0:50 / "+dir+" rw,relatime shared:30 - autofs systemd-1 rw,fd=52,pgrp=1,timeout=0,minproto=5,maxproto=5,direct\n"+
+		"60 50 8:17 / "+dir+" rw,noatime shared:31 - ext4 /dev/sdb1 rw\n")
+	mounts, err := readMountInfo(root.proc("self", "mountinfo"))
+	if err != nil {
+		t.Fatalf("readMountInfo error: %v", err)
+	}
+
+	// By default the trigger is skipped by type and the drive is left
+	// alone so it can expire.
+	if snapshot := filesystemSnapshot(mounts, FSFilter{ExcludeTypes: DefaultFSExcludeTypes}); len(snapshot.Filesystems) != 0 {
+		t.Fatalf("expected no filesystems, got %+v", snapshot.Filesystems)
+	}
+
+	// Listing the mount reports the drive, but never the trigger.
+	for _, filter := range []FSFilter{
+		{IncludeMounts: []string{dir}, ExcludeTypes: DefaultFSExcludeTypes},
+		{IncludeTypes: []string{"ext4"}, ExcludeTypes: DefaultFSExcludeTypes},
+	} {
+		snapshot := filesystemSnapshot(mounts, filter)
+		if len(snapshot.Filesystems) != 1 || snapshot.Filesystems[0].FSType != "ext4" || snapshot.Filesystems[0].Device != "/dev/sdb1" {
+			t.Fatalf("filter %+v got %+v", filter, snapshot.Filesystems)
+		}
+	}
+}
@@ -3,6 +3,7 @@
 package sensors
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -12,38 +13,54 @@ import (
 )
 
 type GPUBusySampler struct {
-	mu      sync.RWMutex
-	utilPct float64
-	path    string
+	lifecycle
+
+	mu    sync.RWMutex
+	cards []drmCard
+	util  map[string]float64
 }
 
 type GPUBusySnapshot struct {
+	GPUs []GPUBusy
+}
+
+type GPUBusy struct {
+	PCIAddr string
+	Card    string
 	UtilPct float64
 }
 
-func NewGPUBusySampler(interval time.Duration) *GPUBusySampler {
-	path := detectGPUBusyPath()
-	s := &GPUBusySampler{path: path}
-	if path != "" {
-		go s.run(interval)
+func NewGPUBusySampler(interval time.Duration, root Root) *GPUBusySampler {
+	cards := detectGPUBusyCards(root)
+	s := &GPUBusySampler{cards: cards, util: make(map[string]float64)}
+	if len(cards) > 0 {
+		s.onStart(interval, s.run)
 	}
 
 	return s
 }
 
-func (s *GPUBusySampler) run(interval time.Duration) {
+func (s *GPUBusySampler) run(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		util, err := readGPUBusy(s.path)
-		if err != nil {
-			continue
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 
-		s.mu.Lock()
-		s.utilPct = util
-		s.mu.Unlock()
+		for _, card := range s.cards {
+			util, err := readGPUBusy(gpuBusyPath(card))
+			if err != nil {
+				continue
+			}
+
+			s.mu.Lock()
+			s.util[card.PCIAddr] = util
+			s.mu.Unlock()
+		}
 	}
 }
 
@@ -51,16 +68,33 @@ func (s *GPUBusySampler) Snapshot() GPUBusySnapshot {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return GPUBusySnapshot{UtilPct: s.utilPct}
+	var snapshot GPUBusySnapshot
+	for _, card := range s.cards {
+		util, ok := s.util[card.PCIAddr]
+		if !ok {
+			continue
+		}
+		snapshot.GPUs = append(snapshot.GPUs, GPUBusy{PCIAddr: card.PCIAddr, Card: card.Name, UtilPct: util})
+	}
+
+	return snapshot
 }
 
-func detectGPUBusyPath() string {
-	matches, err := filepath.Glob("/sys/class/drm/card*/device/gpu_busy_percent")
-	if err != nil || len(matches) == 0 {
-		return ""
+// detectGPUBusyCards returns the DRM cards that expose gpu_busy_percent.
+func detectGPUBusyCards(root Root) []drmCard {
+	var cards []drmCard
+	for _, card := range detectDRMCards(root) {
+		if _, err := os.Stat(gpuBusyPath(card)); err != nil {
+			continue
+		}
+		cards = append(cards, card)
 	}
 
-	return matches[0]
+	return cards
+}
+
+func gpuBusyPath(card drmCard) string {
+	return filepath.Join(card.DeviceDir, "gpu_busy_percent")
 }
 
 func readGPUBusy(path string) (float64, error) {
@@ -0,0 +1,143 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errNoActiveDPMLevel = errors.New("no active dpm level")
+
+type GPUClockSnapshot struct {
+	GPUs []GPUClock
+}
+
+// GPUClock is the active DPM level of an amdgpu card's shader (sclk) and
+// memory (mclk) clocks.
+type GPUClock struct {
+	PCIAddr        string
+	Card           string
+	Readings       GPUReadings
+	ShaderClockMHz float64
+	MemClockMHz    float64
+}
+
+type GPUClockSampler struct {
+	lifecycle
+
+	mu     sync.RWMutex
+	cards  []drmCard
+	clocks map[string]GPUClock
+}
+
+func NewGPUClockSampler(interval time.Duration, root Root) *GPUClockSampler {
+	cards := detectDPMCards(root)
+	s := &GPUClockSampler{cards: cards, clocks: make(map[string]GPUClock)}
+	if len(cards) > 0 {
+		s.onStart(interval, s.run)
+	}
+
+	return s
+}
+
+func (s *GPUClockSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, card := range s.cards {
+			sclk, err := readDPMClock(filepath.Join(card.DeviceDir, "pp_dpm_sclk"))
+			if err != nil {
+				continue
+			}
+			clock := GPUClock{PCIAddr: card.PCIAddr, Card: card.Name, Readings: GPUGraphicsClock, ShaderClockMHz: sclk}
+			// Some APUs have no pp_dpm_mclk; report the shader clock alone.
+			mclk, err := readDPMClock(filepath.Join(card.DeviceDir, "pp_dpm_mclk"))
+			clock.MemClockMHz = clock.Readings.record(GPUMemClock)(mclk, err == nil)
+
+			s.mu.Lock()
+			s.clocks[card.PCIAddr] = clock
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *GPUClockSampler) Snapshot() GPUClockSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var snapshot GPUClockSnapshot
+	for _, card := range s.cards {
+		if clock, ok := s.clocks[card.PCIAddr]; ok {
+			snapshot.GPUs = append(snapshot.GPUs, clock)
+		}
+	}
+
+	return snapshot
+}
+
+func detectDPMCards(root Root) []drmCard {
+	var cards []drmCard
+	for _, card := range detectDRMCards(root) {
+		if _, err := os.Stat(filepath.Join(card.DeviceDir, "pp_dpm_sclk")); err != nil {
+			continue
+		}
+		cards = append(cards, card)
+	}
+
+	return cards
+}
+
+func readDPMClock(path string) (float64, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseDPMClock(string(raw))
+}
+
+// parseDPMClock returns the clock of the level marked with '*' in a
+// pp_dpm_* table such as:
+//
+//	0: 500Mhz
+//	1: 2604Mhz *
+func parseDPMClock(table string) (float64, error) {
+	for _, line := range strings.Split(table, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasSuffix(line, "*") {
+			continue
+		}
+
+		_, level, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(level)
+		if len(fields) == 0 {
+			continue
+		}
+
+		value := strings.TrimSuffix(strings.ToLower(fields[0]), "mhz")
+		mhz, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, err
+		}
+		return mhz, nil
+	}
+
+	return 0, errNoActiveDPMLevel
+}
@@ -0,0 +1,54 @@
+package sensors
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseDPMClock(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		want  float64
+	}{
+		{name: "dgpu sclk", table: "0: 500Mhz \n1: 1895Mhz \n2: 2604Mhz *\n", want: 2604},
+		{name: "mclk", table: "0: 96Mhz \n1: 456Mhz *\n2: 772Mhz \n3: 1249Mhz \n", want: 456},
+		{name: "sleep level", table: "S: 19Mhz *\n0: 500Mhz \n1: 2604Mhz \n", want: 19},
+		{name: "uppercase unit", table: "0: 800MHz *\n", want: 800},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDPMClock(tt.table)
+			if err != nil {
+				t.Fatalf("parseDPMClock error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseDPMClock got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDPMClockWithoutActiveLevel(t *testing.T) {
+	if _, err := parseDPMClock("0: 500Mhz \n1: 2604Mhz \n"); !errors.Is(err, errNoActiveDPMLevel) {
+		t.Fatalf("parseDPMClock err=%v, want errNoActiveDPMLevel", err)
+	}
+}
+
+func TestDetectDPMCards(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeFixture(t, root.Sys, "class/drm/card0/device/uevent", "DRIVER=i915\nPCI_SLOT_NAME=0000:00:02.0\n")
+	writeFixture(t, root.Sys, "class/drm/card1/device/uevent", "DRIVER=amdgpu\nPCI_SLOT_NAME=0000:03:00.0\n")
+	writeFixture(t, root.Sys, "class/drm/card1/device/pp_dpm_sclk", "0: 500Mhz *\n")
+
+	cards := detectDPMCards(root)
+	if len(cards) != 1 || cards[0].Name != "card1" {
+		t.Fatalf("detectDPMCards got %+v", cards)
+	}
+
+	mhz, err := readDPMClock(cards[0].DeviceDir + "/pp_dpm_sclk")
+	if err != nil || mhz != 500 {
+		t.Fatalf("readDPMClock got %v, %v", mhz, err)
+	}
+}
@@ -3,6 +3,7 @@
 package sensors
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -12,73 +13,108 @@ import (
 )
 
 type GPUVRAMSnapshot struct {
+	GPUs []GPUVRAM
+}
+
+type GPUVRAM struct {
+	PCIAddr string
+	Card    string
 	TotalGB float64
 	UsedGB  float64
 	UsedPct float64
 }
 
 type GPUVRAMSampler struct {
-	mu        sync.RWMutex
-	snapshot  GPUVRAMSnapshot
-	usedPath  string
-	totalPath string
+	lifecycle
+
+	mu    sync.RWMutex
+	cards []drmCard
+	vram  map[string]GPUVRAM
 }
 
-func NewGPUVRAMSampler(interval time.Duration) *GPUVRAMSampler {
-	usedPath, totalPath := detectVRAMPaths()
-	s := &GPUVRAMSampler{usedPath: usedPath, totalPath: totalPath}
-	if usedPath != "" && totalPath != "" {
-		go s.run(interval)
+func NewGPUVRAMSampler(interval time.Duration, root Root) *GPUVRAMSampler {
+	cards := detectVRAMCards(root)
+	s := &GPUVRAMSampler{cards: cards, vram: make(map[string]GPUVRAM)}
+	if len(cards) > 0 {
+		s.onStart(interval, s.run)
 	}
 
 	return s
 }
 
-func (s *GPUVRAMSampler) run(interval time.Duration) {
+func (s *GPUVRAMSampler) run(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		snapshot, err := readVRAMSnapshot(s.usedPath, s.totalPath)
-		if err != nil {
-			continue
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 
-		s.mu.Lock()
-		s.snapshot = snapshot
-		s.mu.Unlock()
+		for _, card := range s.cards {
+			usedPath, totalPath := vramPaths(card)
+			vram, err := readVRAM(usedPath, totalPath)
+			if err != nil {
+				continue
+			}
+			vram.PCIAddr = card.PCIAddr
+			vram.Card = card.Name
+
+			s.mu.Lock()
+			s.vram[card.PCIAddr] = vram
+			s.mu.Unlock()
+		}
 	}
 }
 
 func (s *GPUVRAMSampler) Snapshot() GPUVRAMSnapshot {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.snapshot
-}
 
-func detectVRAMPaths() (string, string) {
-	usedMatches, err := filepath.Glob("/sys/class/drm/card*/device/mem_info_vram_used")
-	if err != nil || len(usedMatches) == 0 {
-		return "", ""
+	var snapshot GPUVRAMSnapshot
+	for _, card := range s.cards {
+		if vram, ok := s.vram[card.PCIAddr]; ok {
+			snapshot.GPUs = append(snapshot.GPUs, vram)
+		}
 	}
 
-	totalMatches, err := filepath.Glob("/sys/class/drm/card*/device/mem_info_vram_total")
-	if err != nil || len(totalMatches) == 0 {
-		return "", ""
+	return snapshot
+}
+
+// detectVRAMCards returns the DRM cards that expose both
+// mem_info_vram_used and mem_info_vram_total, so used and total always come
+// from the same card.
+func detectVRAMCards(root Root) []drmCard {
+	var cards []drmCard
+	for _, card := range detectDRMCards(root) {
+		usedPath, totalPath := vramPaths(card)
+		if _, err := os.Stat(usedPath); err != nil {
+			continue
+		}
+		if _, err := os.Stat(totalPath); err != nil {
+			continue
+		}
+		cards = append(cards, card)
 	}
 
-	return usedMatches[0], totalMatches[0]
+	return cards
+}
+
+func vramPaths(card drmCard) (string, string) {
+	return filepath.Join(card.DeviceDir, "mem_info_vram_used"), filepath.Join(card.DeviceDir, "mem_info_vram_total")
 }
 
-func readVRAMSnapshot(usedPath string, totalPath string) (GPUVRAMSnapshot, error) {
+func readVRAM(usedPath string, totalPath string) (GPUVRAM, error) {
 	usedBytes, err := readUintFromFile(usedPath)
 	if err != nil {
-		return GPUVRAMSnapshot{}, err
+		return GPUVRAM{}, err
 	}
 
 	totalBytes, err := readUintFromFile(totalPath)
 	if err != nil {
-		return GPUVRAMSnapshot{}, err
+		return GPUVRAM{}, err
 	}
 
 	usedGB := float64(usedBytes) / (1024.0 * 1024.0 * 1024.0)
@@ -88,7 +124,7 @@ func readVRAMSnapshot(usedPath string, totalPath string) (GPUVRAMSnapshot, error
 		usedPct = 100.0 * usedGB / totalGB
 	}
 
-	return GPUVRAMSnapshot{
+	return GPUVRAM{
 		TotalGB: totalGB,
 		UsedGB:  usedGB,
 		UsedPct: usedPct,
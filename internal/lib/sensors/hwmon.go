@@ -0,0 +1,218 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var errNoHwmonChips = errors.New("no hwmon chips found")
+
+// hwmonInputPattern matches hwmon channel value files, e.g. temp1_input,
+// power1_average or fan2_input.
+var hwmonInputPattern = regexp.MustCompile(`^(temp|power|fan|in)(\d+)_(input|average)$`)
+
+type hwmonChip struct {
+	Name string
+	Dir  string
+	// PCIAddr is the PCI address of the device backing the chip, or empty
+	// for platform and virtual chips.
+	PCIAddr string
+	Inputs  []hwmonInput
+}
+
+type hwmonInput struct {
+	Kind  string
+	Index int
+	Label string
+	// Value is scaled to display units: °C, W, RPM or V.
+	Value float64
+}
+
+// readHwmonChips walks /sys/class/hwmon/hwmon* and reads every temperature,
+// power, fan and voltage channel of each chip.
+func readHwmonChips(root Root) ([]hwmonChip, error) {
+	dirs, err := filepath.Glob(root.sys("class", "hwmon", "hwmon*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return naturalLess(filepath.Base(dirs[i]), filepath.Base(dirs[j]))
+	})
+
+	var chips []hwmonChip
+	for _, dir := range dirs {
+		chip, err := readHwmonChip(dir)
+		if err != nil {
+			continue
+		}
+		chips = append(chips, chip)
+	}
+
+	if len(chips) == 0 {
+		return nil, errNoHwmonChips
+	}
+
+	return chips, nil
+}
+
+func readHwmonChip(dir string) (hwmonChip, error) {
+	name, err := readTrimmedFile(filepath.Join(dir, "name"))
+	if err != nil {
+		return hwmonChip{}, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return hwmonChip{}, err
+	}
+
+	chip := hwmonChip{Name: name, Dir: dir, PCIAddr: pciSlotName(filepath.Join(dir, "device"))}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		m := hwmonInputPattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+
+		kind, suffix := m[1], m[3]
+		channel := kind + m[2]
+		// power channels may expose both _average and _input; prefer _average
+		// which is what `sensors` reports for amdgpu PPT.
+		if kind == "power" && suffix == "input" {
+			if _, err := os.Stat(filepath.Join(dir, channel+"_average")); err == nil {
+				continue
+			}
+		}
+		if seen[channel] {
+			continue
+		}
+
+		raw, err := readTrimmedFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+
+		label, err := readTrimmedFile(filepath.Join(dir, channel+"_label"))
+		if err != nil || label == "" {
+			label = channel
+		}
+
+		index, _ := strconv.Atoi(m[2])
+		chip.Inputs = append(chip.Inputs, hwmonInput{
+			Kind:  kind,
+			Index: index,
+			Label: label,
+			Value: value / hwmonScale(kind),
+		})
+		seen[channel] = true
+	}
+
+	sort.Slice(chip.Inputs, func(i, j int) bool {
+		if chip.Inputs[i].Kind != chip.Inputs[j].Kind {
+			return chip.Inputs[i].Kind < chip.Inputs[j].Kind
+		}
+		return chip.Inputs[i].Index < chip.Inputs[j].Index
+	})
+
+	return chip, nil
+}
+
+// hwmonScale returns the divisor that converts raw sysfs values
+// (millidegrees, microwatts, millivolts) into display units.
+func hwmonScale(kind string) float64 {
+	switch kind {
+	case "temp", "in":
+		return 1_000.0
+	case "power":
+		return 1_000_000.0
+	default:
+		return 1.0
+	}
+}
+
+// firstValue returns the value of the first channel of kind whose label
+// matches one of labels, in order of preference.
+func (c hwmonChip) firstValue(kind string, labels ...string) float64 {
+	value, _ := c.lookup(kind, labels...)
+	return value
+}
+
+// lookup is firstValue, also reporting whether the chip has such a channel.
+func (c hwmonChip) lookup(kind string, labels ...string) (float64, bool) {
+	for _, label := range labels {
+		for _, input := range c.Inputs {
+			if input.Kind == kind && input.Label == label {
+				return input.Value, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func findHwmonChip(chips []hwmonChip, names ...string) (hwmonChip, bool) {
+	for _, name := range names {
+		for _, chip := range chips {
+			if chip.Name == name {
+				return chip, true
+			}
+		}
+	}
+
+	return hwmonChip{}, false
+}
+
+// findHwmonChips returns every chip called name, e.g. one amdgpu chip per
+// card.
+func findHwmonChips(chips []hwmonChip, name string) []hwmonChip {
+	var found []hwmonChip
+	for _, chip := range chips {
+		if chip.Name == name {
+			found = append(found, chip)
+		}
+	}
+
+	return found
+}
+
+func readTrimmedFile(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(raw)), nil
+}
+
+// naturalLess orders names with numeric suffixes numerically, so hwmon10
+// sorts after hwmon9.
+func naturalLess(a string, b string) bool {
+	ap, an := splitNumericSuffix(a)
+	bp, bn := splitNumericSuffix(b)
+	if ap != bp {
+		return a < b
+	}
+
+	return an < bn
+}
+
+func splitNumericSuffix(s string) (string, int) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+
+	n, _ := strconv.Atoi(s[i:])
+	return s[:i], n
+}
@@ -0,0 +1,174 @@
+package sensors
+
+import (
+	"errors"
+	"testing"
+)
+
+func writeHwmonFixture(t *testing.T, root Root) {
+	t.Helper()
+
+	writeFixture(t, root.Sys, "class/hwmon/hwmon2/name", "k10temp\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon2/temp1_input", "72000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon2/temp1_label", "Tctl\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon2/temp3_input", "65500\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon2/temp3_label", "Tccd1\n")
+
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/name", "amdgpu\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/device/uevent", "DRIVER=amdgpu\nPCI_SLOT_NAME=0000:03:00.0\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/temp1_input", "61000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/temp1_label", "edge\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/temp2_input", "75250\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/temp2_label", "junction\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/temp3_input", "80000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/temp3_label", "mem\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/power1_average", "210500000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/power1_input", "199000000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/power1_label", "PPT\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/fan1_input", "1450\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/pwm1", "102\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/power1_cap", "263000000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/power1_cap_default", "303000000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/in0_input", "825\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon10/in0_label", "vddgfx\n")
+}
+
+func TestReadHwmonChips(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeHwmonFixture(t, root)
+
+	chips, err := readHwmonChips(root)
+	if err != nil {
+		t.Fatalf("readHwmonChips error: %v", err)
+	}
+	if len(chips) != 2 || chips[0].Name != "k10temp" || chips[1].Name != "amdgpu" {
+		t.Fatalf("readHwmonChips got %+v", chips)
+	}
+
+	gpu := chips[1]
+	if got := gpu.firstValue("power", "PPT"); got != 210.5 {
+		t.Fatalf("PPT got %v, want 210.5 from power1_average", got)
+	}
+	if got := gpu.firstValue("fan", "fan1"); got != 1450 {
+		t.Fatalf("fan1 got %v, want 1450", got)
+	}
+	if got := gpu.firstValue("in", "vddgfx"); got != 0.825 {
+		t.Fatalf("vddgfx got %v, want 0.825", got)
+	}
+}
+
+func TestReadSensorsPrefersHwmon(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeHwmonFixture(t, root)
+
+	snapshot, err := readSensors(root)
+	if err != nil {
+		t.Fatalf("readSensors error: %v", err)
+	}
+
+	if !snapshot.HasCPUTemp || snapshot.CPUTempC != 72 || snapshot.CPUPackageTempC != 72 {
+		t.Fatalf("readSensors CPU got %+v", *snapshot)
+	}
+
+	want := GPUSensors{
+		PCIAddr:          "0000:03:00.0",
+		Readings:         GPUEdgeTemp | GPUHotspotTemp | GPUMemTemp | GPUPower | GPUFan,
+		EdgeC:            61,
+		HotspotC:         75.25,
+		VramC:            80,
+		PowerW:           210.5,
+		PowerCapW:        263,
+		PowerCapDefaultW: 303,
+		FanRPM:           1450,
+		FanPWMPct:        40,
+	}
+	if len(snapshot.GPUs) != 1 || snapshot.GPUs[0] != want {
+		t.Fatalf("readSensors GPUs got %+v, want [%+v]", snapshot.GPUs, want)
+	}
+}
+
+func TestReadSensorsMultipleGPUs(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeHwmonFixture(t, root)
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/name", "amdgpu\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/device/uevent", "DRIVER=amdgpu\nPCI_SLOT_NAME=0000:7c:00.0\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/temp1_input", "45000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/temp1_label", "edge\n")
+
+	snapshot, err := readSensors(root)
+	if err != nil {
+		t.Fatalf("readSensors error: %v", err)
+	}
+
+	if len(snapshot.GPUs) != 2 {
+		t.Fatalf("readSensors GPUs got %+v, want 2", snapshot.GPUs)
+	}
+	if snapshot.GPUs[0].PCIAddr != "0000:03:00.0" || snapshot.GPUs[0].EdgeC != 61 {
+		t.Fatalf("dGPU got %+v", snapshot.GPUs[0])
+	}
+	if snapshot.GPUs[1].PCIAddr != "0000:7c:00.0" || snapshot.GPUs[1].EdgeC != 45 {
+		t.Fatalf("iGPU got %+v", snapshot.GPUs[1])
+	}
+}
+
+func TestReadSensorsNonPCIGPU(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeHwmonFixture(t, root)
+	// A platform amdgpu chip resolves to its DRM card; one with no card
+	// at all is dropped.
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/name", "amdgpu\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/device/uevent", "DRIVER=amdgpu\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/device/drm/card0/dev", "226:0\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/temp1_input", "45000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon4/temp1_label", "edge\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon5/name", "amdgpu\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon5/temp1_input", "40000\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon5/temp1_label", "edge\n")
+
+	snapshot, err := readSensors(root)
+	if err != nil {
+		t.Fatalf("readSensors error: %v", err)
+	}
+
+	if len(snapshot.GPUs) != 2 || snapshot.GPUs[0].PCIAddr != "0000:03:00.0" {
+		t.Fatalf("readSensors GPUs got %+v, want the dGPU and card0", snapshot.GPUs)
+	}
+	if snapshot.GPUs[1].PCIAddr != "card0" || snapshot.GPUs[1].EdgeC != 45 {
+		t.Fatalf("platform GPU got %+v", snapshot.GPUs[1])
+	}
+}
+
+func TestReadHwmonChipsEmpty(t *testing.T) {
+	_, err := readHwmonChips(Root{Sys: t.TempDir()})
+	if !errors.Is(err, errNoHwmonChips) {
+		t.Fatalf("readHwmonChips err=%v, want errNoHwmonChips", err)
+	}
+}
+
+func TestReadSensorsVoltages(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeHwmonFixture(t, root)
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/name", "nct6798\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/in0_input", "1104\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/in0_label", "Vcore\n")
+	writeFixture(t, root.Sys, "class/hwmon/hwmon3/in1_input", "1016\n")
+
+	snapshot, err := readSensors(root)
+	if err != nil {
+		t.Fatalf("readSensors error: %v", err)
+	}
+
+	want := []Voltage{
+		{Chip: "nct6798", Channel: "in0", Label: "Vcore", Volts: 1.104},
+		{Chip: "nct6798", Channel: "in1", Label: "in1", Volts: 1.016},
+		{Chip: "amdgpu", PCIAddr: "0000:03:00.0", Channel: "in0", Label: "vddgfx", Volts: 0.825},
+	}
+	if len(snapshot.Voltages) != len(want) {
+		t.Fatalf("readSensors voltages got %+v, want %+v", snapshot.Voltages, want)
+	}
+	for i := range want {
+		if snapshot.Voltages[i] != want[i] {
+			t.Fatalf("voltage %d got %+v, want %+v", i, snapshot.Voltages[i], want[i])
+		}
+	}
+}
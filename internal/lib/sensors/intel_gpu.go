@@ -0,0 +1,194 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// intelGPUEngineOrder is the order engines are reported in, matching
+// intel_gpu_top.
+var intelGPUEngineOrder = []string{"render", "video", "video-enhance", "copy", "compute"}
+
+type IntelGPUSnapshot struct {
+	GPUs []IntelGPU
+}
+
+// IntelGPU reports engine utilization for an i915 or xe card. UtilPct is
+// the busiest engine, since engines run in parallel and do not add up.
+type IntelGPU struct {
+	PCIAddr    string
+	Card       string
+	Driver     string
+	Readings   GPUReadings
+	UtilPct    float64
+	Engines    []GPUEngine
+	ActFreqMHz float64
+	MaxFreqMHz float64
+}
+
+type GPUEngine struct {
+	Name    string
+	BusyPct float64
+}
+
+type IntelGPUSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	root     Root
+	cards    []drmCard
+	last     map[drmClientKey]drmClient
+	lastAt   time.Time
+	snapshot IntelGPUSnapshot
+}
+
+func NewIntelGPUSampler(interval time.Duration, root Root) *IntelGPUSampler {
+	cards := detectIntelGPUCards(root)
+	s := &IntelGPUSampler{root: root, cards: cards}
+	if len(cards) > 0 {
+		s.onStart(interval, s.run)
+	}
+
+	return s
+}
+
+func (s *IntelGPUSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		clients := readDRMClients(s.root)
+		now := time.Now()
+
+		s.mu.Lock()
+		if !s.lastAt.IsZero() {
+			s.snapshot = intelGPUSnapshot(s.cards, s.last, clients, now.Sub(s.lastAt))
+		}
+		s.last = clients
+		s.lastAt = now
+		s.mu.Unlock()
+	}
+}
+
+func (s *IntelGPUSampler) Snapshot() IntelGPUSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := IntelGPUSnapshot{GPUs: make([]IntelGPU, 0, len(s.snapshot.GPUs))}
+	for _, gpu := range s.snapshot.GPUs {
+		gpu.Engines = append([]GPUEngine(nil), gpu.Engines...)
+		snapshot.GPUs = append(snapshot.GPUs, gpu)
+	}
+
+	return snapshot
+}
+
+func detectIntelGPUCards(root Root) []drmCard {
+	var cards []drmCard
+	for _, card := range detectDRMCards(root) {
+		if card.Driver == "i915" || card.Driver == "xe" {
+			cards = append(cards, card)
+		}
+	}
+
+	return cards
+}
+
+func intelGPUSnapshot(cards []drmCard, prev map[drmClientKey]drmClient, cur map[drmClientKey]drmClient, elapsed time.Duration) IntelGPUSnapshot {
+	var snapshot IntelGPUSnapshot
+	for _, card := range cards {
+		busy := make(map[string]float64)
+		for key, client := range cur {
+			if client.Driver != card.Driver {
+				continue
+			}
+			// Kernels before 5.19 omit drm-pdev; with a single card the
+			// client can only belong to it.
+			if client.PDev != card.PCIAddr && !(client.PDev == "" && len(cards) == 1) {
+				continue
+			}
+
+			last, ok := prev[key]
+			if !ok {
+				continue
+			}
+			for name, usage := range client.Engines {
+				busy[name] += engineBusyPct(last.Engines[name], usage, elapsed)
+			}
+		}
+
+		gpu := IntelGPU{PCIAddr: card.PCIAddr, Card: card.Name, Driver: card.Driver, Readings: GPUUtil}
+		var hasFreq bool
+		gpu.ActFreqMHz, gpu.MaxFreqMHz, hasFreq = readIntelGPUFreq(card)
+		if hasFreq {
+			gpu.Readings |= GPUGraphicsClock
+		}
+		for _, name := range intelGPUEngineOrder {
+			pct, ok := busy[name]
+			if !ok {
+				continue
+			}
+			pct = min(pct, 100)
+			gpu.Engines = append(gpu.Engines, GPUEngine{Name: name, BusyPct: pct})
+			gpu.UtilPct = max(gpu.UtilPct, pct)
+		}
+
+		snapshot.GPUs = append(snapshot.GPUs, gpu)
+	}
+
+	return snapshot
+}
+
+// engineBusyPct returns how busy an engine was between two readings, spread
+// over all instances of the engine class.
+func engineBusyPct(prev drmEngineUsage, cur drmEngineUsage, elapsed time.Duration) float64 {
+	capacity := float64(max(cur.Capacity, 1))
+
+	if cur.TotalCycles > 0 {
+		total := counterDelta(prev.TotalCycles, cur.TotalCycles)
+		if total == 0 {
+			return 0
+		}
+		return 100.0 * float64(counterDelta(prev.Cycles, cur.Cycles)) / float64(total) / capacity
+	}
+
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return 100.0 * float64(counterDelta(prev.BusyNs, cur.BusyNs)) / float64(elapsed.Nanoseconds()) / capacity
+}
+
+// readIntelGPUFreq reads the actual and maximum GT frequency, from the
+// i915 card attributes or the xe per-GT freq0 directory. ok reports whether
+// the actual frequency file exists.
+func readIntelGPUFreq(card drmCard) (act float64, maxFreq float64, ok bool) {
+	actPath := filepath.Join(filepath.Dir(card.DeviceDir), "gt_act_freq_mhz")
+	maxPath := filepath.Join(filepath.Dir(card.DeviceDir), "gt_max_freq_mhz")
+	if card.Driver == "xe" {
+		freqDir := filepath.Join(card.DeviceDir, "tile0", "gt0", "freq0")
+		actPath, maxPath = filepath.Join(freqDir, "act_freq"), filepath.Join(freqDir, "max_freq")
+	}
+
+	actMHz, err := readUintFromFile(actPath)
+	return float64(actMHz), readUintAsFloat(maxPath), err == nil
+}
+
+func readUintAsFloat(path string) float64 {
+	value, err := readUintFromFile(path)
+	if err != nil {
+		return 0
+	}
+
+	return float64(value)
+}
@@ -0,0 +1,140 @@
+package sensors
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const i915Fdinfo = `pos:	0
+flags:	02100002
+mnt_id:	24
+drm-driver:	i915
+drm-client-id:	7
+drm-pdev:	0000:00:02.0
+drm-engine-render:	9288864723 ns
+drm-engine-copy:	2035071108 ns
+drm-engine-video:	0 ns
+drm-engine-capacity-video:	2
+drm-engine-video-enhance:	0 ns
+`
+
+const xeFdinfo = `pos:	0
+drm-driver:	xe
+drm-client-id:	42
+drm-pdev:	0000:03:00.0
+drm-cycles-rcs:	28257900
+drm-total-cycles-rcs:	7655183225
+drm-cycles-bcs:	0
+drm-total-cycles-bcs:	7655183225
+drm-cycles-vcs:	0
+drm-total-cycles-vcs:	7655183225
+drm-engine-capacity-vcs:	2
+`
+
+func TestParseDRMFdinfoI915(t *testing.T) {
+	client, ok := parseDRMFdinfo(i915Fdinfo)
+	if !ok {
+		t.Fatal("expected i915 fdinfo to parse")
+	}
+	if client.Driver != "i915" || client.ID != "7" || client.PDev != "0000:00:02.0" {
+		t.Fatalf("client identity got %+v", client)
+	}
+	if client.Engines["render"].BusyNs != 9288864723 || client.Engines["copy"].BusyNs != 2035071108 {
+		t.Fatalf("engine counters got %+v", client.Engines)
+	}
+	if client.Engines["video"].Capacity != 2 || len(client.Engines) != 4 {
+		t.Fatalf("engines got %+v", client.Engines)
+	}
+}
+
+func TestParseDRMFdinfoXe(t *testing.T) {
+	client, ok := parseDRMFdinfo(xeFdinfo)
+	if !ok {
+		t.Fatal("expected xe fdinfo to parse")
+	}
+
+	render := client.Engines["render"]
+	if render.Cycles != 28257900 || render.TotalCycles != 7655183225 {
+		t.Fatalf("render engine got %+v", render)
+	}
+	if client.Engines["video"].Capacity != 2 {
+		t.Fatalf("video engine got %+v", client.Engines["video"])
+	}
+}
+
+func TestParseDRMFdinfoIgnoresNonDRMFiles(t *testing.T) {
+	if _, ok := parseDRMFdinfo("pos:\t0\nflags:\t02\nmnt_id:\t15\n"); ok {
+		t.Fatal("expected plain fdinfo to be rejected")
+	}
+}
+
+func TestReadDRMClientsDeduplicatesSharedClients(t *testing.T) {
+	root := Root{Proc: t.TempDir()}
+
+	for _, pid := range []string{"100", "200"} {
+		writeFixture(t, root.Proc, pid+"/fdinfo/5", i915Fdinfo)
+		if err := os.MkdirAll(filepath.Join(root.Proc, pid, "fd"), 0o755); err != nil {
+			t.Fatalf("mkdir fd: %v", err)
+		}
+		if err := os.Symlink("/dev/dri/renderD128", filepath.Join(root.Proc, pid, "fd", "5")); err != nil {
+			t.Fatalf("symlink fd: %v", err)
+		}
+	}
+	writeFixture(t, root.Proc, "100/fdinfo/1", "pos:\t0\n")
+	if err := os.Symlink("/dev/null", filepath.Join(root.Proc, "100", "fd", "1")); err != nil {
+		t.Fatalf("symlink fd: %v", err)
+	}
+
+	clients := readDRMClients(root)
+	if len(clients) != 1 {
+		t.Fatalf("readDRMClients got %d clients, want 1: %+v", len(clients), clients)
+	}
+}
+
+func TestIntelGPUSnapshot(t *testing.T) {
+	sysRoot := t.TempDir()
+	writeFixture(t, sysRoot, "class/drm/card0/gt_act_freq_mhz", "1300\n")
+	writeFixture(t, sysRoot, "class/drm/card0/gt_max_freq_mhz", "2250\n")
+	card := drmCard{Name: "card0", Driver: "i915", PCIAddr: "0000:00:02.0", DeviceDir: filepath.Join(sysRoot, "class/drm/card0/device")}
+
+	key := drmClientKey{PDev: "0000:00:02.0", ID: "7"}
+	prev := map[drmClientKey]drmClient{key: {Driver: "i915", PDev: key.PDev, ID: key.ID, Engines: map[string]drmEngineUsage{
+		"render": {BusyNs: 1_000_000_000},
+		"video":  {BusyNs: 0, Capacity: 2},
+	}}}
+	cur := map[drmClientKey]drmClient{key: {Driver: "i915", PDev: key.PDev, ID: key.ID, Engines: map[string]drmEngineUsage{
+		"render": {BusyNs: 1_750_000_000},
+		"video":  {BusyNs: 500_000_000, Capacity: 2},
+	}}}
+
+	snapshot := intelGPUSnapshot([]drmCard{card}, prev, cur, time.Second)
+	if len(snapshot.GPUs) != 1 {
+		t.Fatalf("expected 1 GPU, got %+v", snapshot.GPUs)
+	}
+
+	gpu := snapshot.GPUs[0]
+	if len(gpu.Engines) != 2 || gpu.Engines[0].Name != "render" || gpu.Engines[1].Name != "video" {
+		t.Fatalf("engines got %+v", gpu.Engines)
+	}
+	if math.Abs(gpu.Engines[0].BusyPct-75) > 1e-9 || math.Abs(gpu.Engines[1].BusyPct-25) > 1e-9 {
+		t.Fatalf("engine busy got %+v, want render=75 video=25", gpu.Engines)
+	}
+	if math.Abs(gpu.UtilPct-75) > 1e-9 {
+		t.Fatalf("UtilPct got %v, want busiest engine 75", gpu.UtilPct)
+	}
+	if gpu.ActFreqMHz != 1300 || gpu.MaxFreqMHz != 2250 {
+		t.Fatalf("freq got %v/%v, want 1300/2250", gpu.ActFreqMHz, gpu.MaxFreqMHz)
+	}
+}
+
+func TestEngineBusyPctCycles(t *testing.T) {
+	prev := drmEngineUsage{Cycles: 100, TotalCycles: 1000}
+	cur := drmEngineUsage{Cycles: 400, TotalCycles: 2000}
+
+	if got := engineBusyPct(prev, cur, time.Second); math.Abs(got-30) > 1e-9 {
+		t.Fatalf("engineBusyPct got %v, want 30", got)
+	}
+}
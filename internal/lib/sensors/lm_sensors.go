@@ -4,40 +4,89 @@ package sensors
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+// lmSensorsVoltageChannel matches voltage channel names in `sensors -j`
+// feature fields, e.g. in0 from in0_input.
+var lmSensorsVoltageChannel = regexp.MustCompile(`^in\d+$`)
+
+// LmSensorsSnapshot holds the CPU temperatures when HasCPUTemp is set,
+// i.e. when a k10temp or coretemp chip has a matching channel. Both
+// temperatures are read from the same channels.
 type LmSensorsSnapshot struct {
 	CPUTempC        float64
 	CPUPackageTempC float64
-	GPUEdgeC        float64
-	GPUHotspotC     float64
-	GPUVramC        float64
-	GPUPowerW       float64
+	HasCPUTemp      bool
+	GPUs            []GPUSensors
+	Voltages        []Voltage
+}
+
+// Voltage is one inN channel of a hwmon chip, e.g. Vcore or +12V on a
+// Super I/O chip, or vddgfx on amdgpu. Label falls back to the channel name
+// when the driver does not provide one.
+type Voltage struct {
+	Chip    string
+	PCIAddr string
+	Channel string
+	Label   string
+	Volts   float64
+}
+
+// GPUSensors holds the hwmon readings of one GPU, keyed by the same PCI
+// address the DRM samplers use. Readings flags the temperatures, power and
+// fan speed the chip has channels for. Power caps and fan duty are only
+// available from sysfs, not from the `sensors -j` fallback.
+type GPUSensors struct {
+	PCIAddr          string
+	Readings         GPUReadings
+	EdgeC            float64
+	HotspotC         float64
+	VramC            float64
+	PowerW           float64
+	PowerCapW        float64
+	PowerCapDefaultW float64
+	FanRPM           float64
+	FanPWMPct        float64
 }
 
 type LmSensorsSampler struct {
+	lifecycle
+
 	mu       sync.RWMutex
 	snapshot LmSensorsSnapshot
+	root     Root
 }
 
-func NewLmSensorsSampler(interval time.Duration) *LmSensorsSampler {
-	s := &LmSensorsSampler{}
-	go s.run(interval)
+func NewLmSensorsSampler(interval time.Duration, root Root) *LmSensorsSampler {
+	s := &LmSensorsSampler{root: root}
+	s.onStart(interval, s.run)
 
 	return s
 }
 
-func (s *LmSensorsSampler) run(interval time.Duration) {
+func (s *LmSensorsSampler) run(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		snapshot, err := readLmSensors()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot, err := readSensors(s.root)
 		if err != nil {
 			continue
 		}
@@ -51,7 +100,80 @@ func (s *LmSensorsSampler) run(interval time.Duration) {
 func (s *LmSensorsSampler) Snapshot() LmSensorsSnapshot {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.snapshot
+
+	snapshot := s.snapshot
+	snapshot.GPUs = append([]GPUSensors(nil), s.snapshot.GPUs...)
+	snapshot.Voltages = append([]Voltage(nil), s.snapshot.Voltages...)
+	return snapshot
+}
+
+// readSensors reads the hwmon chips from sysfs, falling back to
+// `sensors -j` when no hwmon chips are available.
+func readSensors(root Root) (*LmSensorsSnapshot, error) {
+	chips, err := readHwmonChips(root)
+	if err != nil {
+		return readLmSensors()
+	}
+
+	return hwmonSnapshot(chips), nil
+}
+
+func hwmonSnapshot(chips []hwmonChip) *LmSensorsSnapshot {
+	snapshot := &LmSensorsSnapshot{}
+
+	if chip, ok := findHwmonChip(chips, "k10temp"); ok {
+		snapshot.CPUTempC, snapshot.HasCPUTemp = chip.lookup("temp", "Tctl", "Tdie", "temp1")
+		snapshot.CPUPackageTempC = chip.firstValue("temp", "Tdie", "Tctl", "temp1")
+	} else if chip, ok := findHwmonChip(chips, "coretemp"); ok {
+		snapshot.CPUTempC, snapshot.HasCPUTemp = chip.lookup("temp", "Package id 0", "Core 0")
+		snapshot.CPUPackageTempC = chip.firstValue("temp", "Package id 0", "Core 0")
+	}
+
+	for _, chip := range findHwmonChips(chips, "amdgpu") {
+		// Non-PCI cards are keyed by their DRM card name, as the DRM
+		// samplers do. A chip that cannot be tied to a card is dropped
+		// rather than shown as a second GPU.
+		addr := chip.PCIAddr
+		if addr == "" {
+			addr = drmCardName(filepath.Join(chip.Dir, "device"))
+		}
+		if addr == "" {
+			continue
+		}
+
+		gpu := GPUSensors{
+			PCIAddr:          addr,
+			PowerCapW:        readMicrowattsAsWatts(filepath.Join(chip.Dir, "power1_cap")),
+			PowerCapDefaultW: readMicrowattsAsWatts(filepath.Join(chip.Dir, "power1_cap_default")),
+		}
+		gpu.EdgeC = gpu.Readings.record(GPUEdgeTemp)(chip.lookup("temp", "edge"))
+		gpu.HotspotC = gpu.Readings.record(GPUHotspotTemp)(chip.lookup("temp", "junction"))
+		gpu.VramC = gpu.Readings.record(GPUMemTemp)(chip.lookup("temp", "mem"))
+		gpu.PowerW = gpu.Readings.record(GPUPower)(chip.lookup("power", "PPT"))
+		gpu.FanRPM = gpu.Readings.record(GPUFan)(chip.lookup("fan", "fan1"))
+		gpu.FanPWMPct, _ = readPWMPct(filepath.Join(chip.Dir, "pwm1"))
+		snapshot.GPUs = append(snapshot.GPUs, gpu)
+	}
+	sort.Slice(snapshot.GPUs, func(i, j int) bool {
+		return snapshot.GPUs[i].PCIAddr < snapshot.GPUs[j].PCIAddr
+	})
+
+	for _, chip := range chips {
+		for _, input := range chip.Inputs {
+			if input.Kind != "in" {
+				continue
+			}
+			snapshot.Voltages = append(snapshot.Voltages, Voltage{
+				Chip:    chip.Name,
+				PCIAddr: chip.PCIAddr,
+				Channel: "in" + strconv.Itoa(input.Index),
+				Label:   input.Label,
+				Volts:   input.Value,
+			})
+		}
+	}
+
+	return snapshot
 }
 
 func readLmSensors() (*LmSensorsSnapshot, error) {
@@ -72,23 +194,142 @@ func readLmSensors() (*LmSensorsSnapshot, error) {
 	snapshot := &LmSensorsSnapshot{}
 
 	if chip, ok := findChip(data, "k10temp"); ok {
-		snapshot.CPUTempC = findFirstValue(chip, []string{"Tctl", "Tdie"}, "temp1_input")
+		snapshot.CPUTempC, snapshot.HasCPUTemp = lookupValue(chip, []string{"Tctl", "Tdie"}, "temp1_input")
 		snapshot.CPUPackageTempC = findFirstValue(chip, []string{"Tdie", "Tctl"}, "temp1_input")
 	} else if chip, ok := findChip(data, "coretemp"); ok {
-		snapshot.CPUTempC = findFirstValue(chip, []string{"Package id 0", "Core 0"}, "temp1_input")
+		snapshot.CPUTempC, snapshot.HasCPUTemp = lookupValue(chip, []string{"Package id 0", "Core 0"}, "temp1_input")
 		snapshot.CPUPackageTempC = findFirstValue(chip, []string{"Package id 0", "Core 0"}, "temp1_input")
 	}
 
-	if chip, ok := findChip(data, "amdgpu"); ok {
-		snapshot.GPUEdgeC = findFirstValue(chip, []string{"edge"}, "temp1_input")
-		snapshot.GPUHotspotC = findFirstValue(chip, []string{"junction"}, "temp2_input")
-		snapshot.GPUVramC = findFirstValue(chip, []string{"mem"}, "temp3_input")
-		snapshot.GPUPowerW = findFirstValue(chip, []string{"PPT"}, "power1_average")
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if strings.HasPrefix(key, "amdgpu") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		chip, ok := data[key].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		// `sensors -j` has no device link to resolve, so only PCI chips
+		// can be matched to a card.
+		addr := lmSensorsChipPCIAddr(key)
+		if addr == "" {
+			continue
+		}
+
+		gpu := GPUSensors{PCIAddr: addr}
+		gpu.EdgeC = gpu.Readings.record(GPUEdgeTemp)(lookupValue(chip, []string{"edge"}, "temp1_input"))
+		gpu.HotspotC = gpu.Readings.record(GPUHotspotTemp)(lookupValue(chip, []string{"junction"}, "temp2_input"))
+		gpu.VramC = gpu.Readings.record(GPUMemTemp)(lookupValue(chip, []string{"mem"}, "temp3_input"))
+		gpu.PowerW = gpu.Readings.record(GPUPower)(lookupValue(chip, []string{"PPT"}, "power1_average"))
+		snapshot.GPUs = append(snapshot.GPUs, gpu)
 	}
 
+	snapshot.Voltages = lmSensorsVoltages(data)
+
 	return snapshot, nil
 }
 
+// lmSensorsVoltages collects every inN_input feature from `sensors -j`
+// output, where the feature name is the label.
+func lmSensorsVoltages(data map[string]any) []Voltage {
+	chipNames := make([]string, 0, len(data))
+	for name := range data {
+		chipNames = append(chipNames, name)
+	}
+	sort.Strings(chipNames)
+
+	var voltages []Voltage
+	for _, chipName := range chipNames {
+		chip, ok := data[chipName].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		var chipVoltages []Voltage
+		for label, feature := range chip {
+			fields, ok := feature.(map[string]any)
+			if !ok {
+				continue
+			}
+			for field, raw := range fields {
+				channel, ok := strings.CutSuffix(field, "_input")
+				if !ok || !lmSensorsVoltageChannel.MatchString(channel) {
+					continue
+				}
+				volts, ok := parseSensorValue(raw)
+				if !ok {
+					continue
+				}
+				chipVoltages = append(chipVoltages, Voltage{
+					Chip:    strings.SplitN(chipName, "-", 2)[0],
+					PCIAddr: lmSensorsChipPCIAddr(chipName),
+					Channel: channel,
+					Label:   label,
+					Volts:   volts,
+				})
+			}
+		}
+		sort.Slice(chipVoltages, func(i, j int) bool {
+			return naturalLess(chipVoltages[i].Channel, chipVoltages[j].Channel)
+		})
+		voltages = append(voltages, chipVoltages...)
+	}
+
+	return voltages
+}
+
+// lmSensorsChipPCIAddr is lmSensorsPCIAddr for PCI chips and empty for
+// ISA/platform chips such as nct6798-isa-0290.
+func lmSensorsChipPCIAddr(chipName string) string {
+	if !strings.Contains(chipName, "-pci-") {
+		return ""
+	}
+
+	return lmSensorsPCIAddr(chipName)
+}
+
+func readMicrowattsAsWatts(path string) float64 {
+	value, err := readUintFromFile(path)
+	if err != nil {
+		return 0
+	}
+
+	return float64(value) / 1_000_000.0
+}
+
+// readPWMPct converts a hwmon pwmN duty cycle (0-255) to a percentage. It
+// reports false when the chip has no such pwm file.
+func readPWMPct(path string) (float64, bool) {
+	value, err := readUintFromFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	return 100.0 * float64(value) / 255.0, true
+}
+
+// lmSensorsPCIAddr turns a libsensors chip name such as amdgpu-pci-0300
+// into the sysfs PCI address 0000:03:00.0. libsensors encodes the address
+// as (bus << 8) | (device << 3) | function and drops the domain.
+func lmSensorsPCIAddr(chipName string) string {
+	idx := strings.LastIndex(chipName, "-pci-")
+	if idx < 0 {
+		return chipName
+	}
+
+	encoded, err := strconv.ParseUint(chipName[idx+len("-pci-"):], 16, 16)
+	if err != nil {
+		return chipName
+	}
+
+	return fmt.Sprintf("0000:%02x:%02x.%x", encoded>>8, (encoded>>3)&0x1f, encoded&0x7)
+}
+
 func findChip(data map[string]any, prefixes ...string) (map[string]any, bool) {
 	for key, value := range data {
 		for _, prefix := range prefixes {
@@ -104,6 +345,13 @@ func findChip(data map[string]any, prefixes ...string) (map[string]any, bool) {
 }
 
 func findFirstValue(chip map[string]any, sections []string, field string) float64 {
+	value, _ := lookupValue(chip, sections, field)
+	return value
+}
+
+// lookupValue is findFirstValue, also reporting whether any of the
+// sections has the field.
+func lookupValue(chip map[string]any, sections []string, field string) (float64, bool) {
 	for _, section := range sections {
 		sectionData, ok := chip[section].(map[string]any)
 		if !ok {
@@ -111,11 +359,11 @@ func findFirstValue(chip map[string]any, sections []string, field string) float6
 		}
 
 		if value, ok := parseSensorValue(sectionData[field]); ok {
-			return value
+			return value, true
 		}
 	}
 
-	return 0
+	return 0, false
 }
 
 func parseSensorValue(value any) (float64, bool) {
@@ -91,3 +91,47 @@ func TestAMDPackageTempSelectionFallsBackToTctl(t *testing.T) {
 		t.Fatalf("AMD package temp fallback got %v, want 71.25", got)
 	}
 }
+
+func TestLmSensorsPCIAddr(t *testing.T) {
+	tests := map[string]string{
+		"amdgpu-pci-0300": "0000:03:00.0",
+		"amdgpu-pci-7c00": "0000:7c:00.0",
+		"amdgpu-pci-0a09": "0000:0a:01.1",
+		"amdgpu-isa-0000": "amdgpu-isa-0000",
+	}
+
+	for input, want := range tests {
+		if got := lmSensorsPCIAddr(input); got != want {
+			t.Fatalf("lmSensorsPCIAddr(%q) got %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestLmSensorsVoltages(t *testing.T) {
+	data := map[string]any{
+		"nct6798-isa-0290": map[string]any{
+			"Adapter": "ISA adapter",
+			"+12V":    map[string]any{"in4_input": json.Number("12.096"), "in4_min": json.Number("10.2")},
+			"Vcore":   map[string]any{"in0_input": json.Number("1.104")},
+			"SYSTIN":  map[string]any{"temp1_input": json.Number("34.0")},
+		},
+		"amdgpu-pci-0300": map[string]any{
+			"vddgfx": map[string]any{"in0_input": json.Number("0.825")},
+		},
+	}
+
+	got := lmSensorsVoltages(data)
+	want := []Voltage{
+		{Chip: "amdgpu", PCIAddr: "0000:03:00.0", Channel: "in0", Label: "vddgfx", Volts: 0.825},
+		{Chip: "nct6798", Channel: "in0", Label: "Vcore", Volts: 1.104},
+		{Chip: "nct6798", Channel: "in4", Label: "+12V", Volts: 12.096},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("lmSensorsVoltages got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("voltage %d got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type NetIOSnapshot struct {
+	Interfaces []NetInterface
+}
+
+// NetInterface is the traffic of one network interface over the last
+// interval. SpeedMbps is the negotiated link speed, or 0 when the driver
+// does not report one (e.g. Wi-Fi).
+type NetInterface struct {
+	Name            string
+	State           string
+	SpeedMbps       float64
+	RxBytesPerSec   float64
+	TxBytesPerSec   float64
+	RxPacketsPerSec float64
+	TxPacketsPerSec float64
+	RxErrorsPerSec  float64
+	TxErrorsPerSec  float64
+}
+
+type netDevStat struct {
+	RxBytes   uint64
+	RxPackets uint64
+	RxErrors  uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxErrors  uint64
+}
+
+type NetIOSampler struct {
+	lifecycle
+
+	mu         sync.RWMutex
+	devPath    string
+	netDir     string
+	includeAll bool
+	last       map[string]netDevStat
+	lastAt     time.Time
+	snapshot   NetIOSnapshot
+}
+
+// NewNetIOSampler samples /proc/net/dev. By default loopback, bridges and
+// veth pairs are skipped; includeAll reports every interface.
+func NewNetIOSampler(interval time.Duration, root Root, includeAll bool) *NetIOSampler {
+	s := &NetIOSampler{
+		devPath:    root.proc("net", "dev"),
+		netDir:     root.sys("class", "net"),
+		includeAll: includeAll,
+	}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *NetIOSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats, err := readNetDev(s.devPath)
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+
+		// Rates use the time actually elapsed since the last read, which a
+		// late tick stretches past interval.
+		s.mu.Lock()
+		if s.last != nil {
+			s.snapshot = netIOSnapshot(s.netDir, s.last, stats, now.Sub(s.lastAt), s.keepInterface)
+		}
+		s.last = stats
+		s.lastAt = now
+		s.mu.Unlock()
+	}
+}
+
+func (s *NetIOSampler) Snapshot() NetIOSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return NetIOSnapshot{Interfaces: append([]NetInterface(nil), s.snapshot.Interfaces...)}
+}
+
+func (s *NetIOSampler) keepInterface(name string) bool {
+	if s.includeAll {
+		return true
+	}
+
+	return !isVirtualInterface(s.netDir, name)
+}
+
+// isVirtualInterface reports whether name is loopback, a bridge or one end
+// of a veth pair, which would otherwise double-count container traffic.
+func isVirtualInterface(netDir string, name string) bool {
+	if name == "lo" || strings.HasPrefix(name, "veth") {
+		return true
+	}
+
+	_, err := os.Stat(filepath.Join(netDir, name, "bridge"))
+	return err == nil
+}
+
+func netIOSnapshot(netDir string, prev map[string]netDevStat, cur map[string]netDevStat, elapsed time.Duration, keep func(string) bool) NetIOSnapshot {
+	var snapshot NetIOSnapshot
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return snapshot
+	}
+
+	rate := func(prev, cur uint64) float64 {
+		return float64(counterDelta(prev, cur)) / seconds
+	}
+
+	for name, c := range cur {
+		p, ok := prev[name]
+		if !ok || !keep(name) {
+			continue
+		}
+
+		state, _ := readTrimmedFile(filepath.Join(netDir, name, "operstate"))
+		iface := NetInterface{
+			Name:            name,
+			State:           state,
+			RxBytesPerSec:   rate(p.RxBytes, c.RxBytes),
+			TxBytesPerSec:   rate(p.TxBytes, c.TxBytes),
+			RxPacketsPerSec: rate(p.RxPackets, c.RxPackets),
+			TxPacketsPerSec: rate(p.TxPackets, c.TxPackets),
+			RxErrorsPerSec:  rate(p.RxErrors, c.RxErrors),
+			TxErrorsPerSec:  rate(p.TxErrors, c.TxErrors),
+		}
+		// speed is -1 or unreadable while the link is down or unknown.
+		if raw, err := readTrimmedFile(filepath.Join(netDir, name, "speed")); err == nil {
+			if speed, err := strconv.ParseFloat(raw, 64); err == nil && speed > 0 {
+				iface.SpeedMbps = speed
+			}
+		}
+
+		snapshot.Interfaces = append(snapshot.Interfaces, iface)
+	}
+
+	sort.Slice(snapshot.Interfaces, func(i, j int) bool {
+		return naturalLess(snapshot.Interfaces[i].Name, snapshot.Interfaces[j].Name)
+	})
+
+	return snapshot
+}
+
+func readNetDev(path string) (map[string]netDevStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	stats := make(map[string]netDevStat)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// The two header lines have no "iface:" prefix and are skipped.
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 16 {
+			continue
+		}
+
+		var values [16]uint64
+		for i := range values {
+			values[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+
+		stats[strings.TrimSpace(name)] = netDevStat{
+			RxBytes:   values[0],
+			RxPackets: values[1],
+			RxErrors:  values[2],
+			TxBytes:   values[8],
+			TxPackets: values[9],
+			TxErrors:  values[10],
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
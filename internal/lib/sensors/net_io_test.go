@@ -0,0 +1,65 @@
+package sensors
+
+import (
+	"testing"
+	"time"
+)
+
+const netDevHeader = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+`
+
+func TestNetIOSnapshot(t *testing.T) {
+	root := Root{Proc: t.TempDir(), Sys: t.TempDir()}
+	writeFixture(t, root.Proc, "net/dev.0", netDevHeader+
+		"    lo: 5000 50 0 0 0 0 0 0 5000 50 0 0 0 0 0 0\n"+
+		"enp5s0: 1000000 1000 0 0 0 0 0 0 200000 500 0 0 0 0 0 0\n"+
+		"docker0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n"+
+		"vethab12: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n")
+	writeFixture(t, root.Proc, "net/dev.1", netDevHeader+
+		"    lo: 9000 90 0 0 0 0 0 0 9000 90 0 0 0 0 0 0\n"+
+		"enp5s0: 251000000 21000 4 0 0 0 0 0 1200000 1500 0 0 0 0 0 0\n"+
+		"docker0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n"+
+		"vethab12: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n")
+	writeFixture(t, root.Sys, "class/net/enp5s0/operstate", "up\n")
+	writeFixture(t, root.Sys, "class/net/enp5s0/speed", "2500\n")
+	writeFixture(t, root.Sys, "class/net/docker0/operstate", "down\n")
+	writeFixture(t, root.Sys, "class/net/docker0/bridge/stp_state", "0\n")
+
+	prev, err := readNetDev(root.proc("net", "dev.0"))
+	if err != nil {
+		t.Fatalf("readNetDev error: %v", err)
+	}
+	cur, err := readNetDev(root.proc("net", "dev.1"))
+	if err != nil {
+		t.Fatalf("readNetDev error: %v", err)
+	}
+	if len(cur) != 4 {
+		t.Fatalf("readNetDev got %d interfaces, want 4", len(cur))
+	}
+
+	s := &NetIOSampler{netDir: root.sys("class", "net")}
+	snapshot := netIOSnapshot(s.netDir, prev, cur, 2*time.Second, s.keepInterface)
+
+	if len(snapshot.Interfaces) != 1 {
+		t.Fatalf("expected only enp5s0, got %+v", snapshot.Interfaces)
+	}
+	want := NetInterface{
+		Name:            "enp5s0",
+		State:           "up",
+		SpeedMbps:       2500,
+		RxBytesPerSec:   125_000_000,
+		TxBytesPerSec:   500_000,
+		RxPacketsPerSec: 10_000,
+		TxPacketsPerSec: 500,
+		RxErrorsPerSec:  2,
+	}
+	if snapshot.Interfaces[0] != want {
+		t.Fatalf("enp5s0 got %+v, want %+v", snapshot.Interfaces[0], want)
+	}
+
+	s.includeAll = true
+	if all := netIOSnapshot(s.netDir, prev, cur, 2*time.Second, s.keepInterface); len(all.Interfaces) != 4 {
+		t.Fatalf("includeAll expected 4 interfaces, got %+v", all.Interfaces)
+	}
+}
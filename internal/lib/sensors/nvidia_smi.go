@@ -0,0 +1,189 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"encoding/csv"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	nvidiaSMICommand = "nvidia-smi"
+	// nvidiaSMITimeout bounds one query. Without persistence mode the
+	// driver is initialised on every run, which often takes over a second.
+	nvidiaSMITimeout = 5 * time.Second
+)
+
+// nvidiaSMIFields are queried in this order; parseNvidiaSMI relies on it.
+var nvidiaSMIFields = []string{
+	"pci.bus_id",
+	"index",
+	"name",
+	"utilization.gpu",
+	"memory.used",
+	"memory.total",
+	"temperature.gpu",
+	"temperature.memory",
+	"power.draw",
+	"clocks.gr",
+	"clocks.mem",
+}
+
+type NvidiaSMISnapshot struct {
+	GPUs []NvidiaGPU
+}
+
+// NvidiaGPU is one row of `nvidia-smi --query-gpu`. Fields the card reports
+// as [N/A] or [Not Supported] are left at 0 and missing from Readings.
+type NvidiaGPU struct {
+	PCIAddr          string
+	Index            int
+	Name             string
+	Readings         GPUReadings
+	UtilPct          float64
+	VramUsedGB       float64
+	VramTotalGB      float64
+	VramUsedPct      float64
+	TempC            float64
+	VramTempC        float64
+	PowerW           float64
+	GraphicsClockMHz float64
+	MemClockMHz      float64
+}
+
+type NvidiaSMISampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	snapshot NvidiaSMISnapshot
+	path     string
+}
+
+// NewNvidiaSMISampler polls nvidia-smi when it is on PATH and does nothing
+// otherwise, so it is safe to start on machines without an NVIDIA card.
+func NewNvidiaSMISampler(interval time.Duration) *NvidiaSMISampler {
+	path, err := exec.LookPath(nvidiaSMICommand)
+	s := &NvidiaSMISampler{path: path}
+	if err == nil {
+		s.onStart(interval, s.run)
+	}
+
+	return s
+}
+
+func (s *NvidiaSMISampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot, err := readNvidiaSMI(ctx, s.path, nvidiaSMITimeout)
+		// A query can outlast interval; restart the ticker so the ticks
+		// that fired meanwhile are skipped rather than run back to back.
+		ticker.Reset(interval)
+		if err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		s.snapshot = snapshot
+		s.mu.Unlock()
+	}
+}
+
+func (s *NvidiaSMISampler) Snapshot() NvidiaSMISnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return NvidiaSMISnapshot{GPUs: append([]NvidiaGPU(nil), s.snapshot.GPUs...)}
+}
+
+// readNvidiaSMI runs a single query, giving up after timeout so a wedged
+// driver does not stall the sampler, or as soon as ctx is cancelled.
+func readNvidiaSMI(ctx context.Context, path string, timeout time.Duration) (NvidiaSMISnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path,
+		"--query-gpu="+strings.Join(nvidiaSMIFields, ","),
+		"--format=csv,noheader,nounits",
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return NvidiaSMISnapshot{}, err
+	}
+
+	return parseNvidiaSMI(string(output))
+}
+
+func parseNvidiaSMI(output string) (NvidiaSMISnapshot, error) {
+	reader := csv.NewReader(strings.NewReader(output))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = len(nvidiaSMIFields)
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return NvidiaSMISnapshot{}, err
+	}
+
+	var snapshot NvidiaSMISnapshot
+	for _, record := range records {
+		// Fields the card does not support read [N/A] or [Not Supported]
+		// and fail to parse.
+		num := func(i int) (float64, bool) {
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil {
+				return 0, false
+			}
+			return v, true
+		}
+		value := func(i int) float64 {
+			v, _ := num(i)
+			return v
+		}
+
+		index, _ := strconv.Atoi(strings.TrimSpace(record[1]))
+		gpu := NvidiaGPU{
+			PCIAddr:     nvidiaPCIAddr(record[0]),
+			Index:       index,
+			Name:        strings.TrimSpace(record[2]),
+			VramUsedGB:  value(4) / 1024.0,
+			VramTotalGB: value(5) / 1024.0,
+		}
+		gpu.UtilPct = gpu.Readings.record(GPUUtil)(num(3))
+		gpu.TempC = gpu.Readings.record(GPUEdgeTemp)(num(6))
+		gpu.VramTempC = gpu.Readings.record(GPUMemTemp)(num(7))
+		gpu.PowerW = gpu.Readings.record(GPUPower)(num(8))
+		gpu.GraphicsClockMHz = gpu.Readings.record(GPUGraphicsClock)(num(9))
+		gpu.MemClockMHz = gpu.Readings.record(GPUMemClock)(num(10))
+		if gpu.VramTotalGB > 0 {
+			gpu.VramUsedPct = 100.0 * gpu.VramUsedGB / gpu.VramTotalGB
+		}
+
+		snapshot.GPUs = append(snapshot.GPUs, gpu)
+	}
+
+	return snapshot, nil
+}
+
+// nvidiaPCIAddr converts nvidia-smi's 00000000:01:00.0 bus id into the
+// sysfs form 0000:01:00.0 used to key GPUs elsewhere.
+func nvidiaPCIAddr(busID string) string {
+	addr := strings.ToLower(strings.TrimSpace(busID))
+	domain, rest, ok := strings.Cut(addr, ":")
+	if !ok || len(domain) <= 4 {
+		return addr
+	}
+
+	return domain[len(domain)-4:] + ":" + rest
+}
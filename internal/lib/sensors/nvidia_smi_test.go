@@ -0,0 +1,95 @@
+package sensors
+
+import (
+	"context"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const nvidiaSMIFixture = `00000000:01:00.0, 0, NVIDIA GeForce RTX 4080, 97, 12288, 16384, 71, [N/A], 301.45, 2715, 11201
+00000000:41:00.0, 1, NVIDIA RTX A2000, 0, 512, 6138, 38, [Not Supported], 9.12, 210, 405
+`
+
+func TestParseNvidiaSMI(t *testing.T) {
+	snapshot, err := parseNvidiaSMI(nvidiaSMIFixture)
+	if err != nil {
+		t.Fatalf("parseNvidiaSMI error: %v", err)
+	}
+	if len(snapshot.GPUs) != 2 {
+		t.Fatalf("expected 2 GPUs, got %+v", snapshot.GPUs)
+	}
+
+	gpu := snapshot.GPUs[0]
+	if gpu.PCIAddr != "0000:01:00.0" || gpu.Index != 0 || gpu.Name != "NVIDIA GeForce RTX 4080" {
+		t.Fatalf("identity mismatch: got %+v", gpu)
+	}
+	if gpu.UtilPct != 97 || gpu.TempC != 71 || gpu.VramTempC != 0 || gpu.PowerW != 301.45 {
+		t.Fatalf("readings mismatch: got %+v", gpu)
+	}
+	if gpu.GraphicsClockMHz != 2715 || gpu.MemClockMHz != 11201 {
+		t.Fatalf("clocks mismatch: got %+v", gpu)
+	}
+	if gpu.Readings.Has(GPUMemTemp) || !gpu.Readings.Has(GPUUtil|GPUEdgeTemp|GPUPower|GPUGraphicsClock|GPUMemClock) {
+		t.Fatalf("readings flags got %b", gpu.Readings)
+	}
+	if math.Abs(gpu.VramUsedGB-12) > 1e-9 || math.Abs(gpu.VramUsedPct-75) > 1e-9 {
+		t.Fatalf("VRAM mismatch: got %+v", gpu)
+	}
+	if snapshot.GPUs[1].PCIAddr != "0000:41:00.0" || snapshot.GPUs[1].VramTempC != 0 {
+		t.Fatalf("second GPU mismatch: got %+v", snapshot.GPUs[1])
+	}
+}
+
+func TestParseNvidiaSMIRejectsShortRows(t *testing.T) {
+	if _, err := parseNvidiaSMI("00000000:01:00.0, 0, NVIDIA GeForce RTX 4080, 97\n"); err == nil {
+		t.Fatal("expected error for a row missing fields")
+	}
+}
+
+func TestReadNvidiaSMIRunsCommandOnPath(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "query.csv", nvidiaSMIFixture)
+	writeFixture(t, dir, nvidiaSMICommand, "#!/bin/sh\n"+
+		"case \"$1\" in --query-gpu=pci.bus_id,*) ;; *) exit 2 ;; esac\n"+
+		"cat \""+filepath.Join(dir, "query.csv")+"\"\n")
+	if err := os.Chmod(filepath.Join(dir, nvidiaSMICommand), 0o755); err != nil {
+		t.Fatalf("chmod fake nvidia-smi: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	path, err := exec.LookPath(nvidiaSMICommand)
+	if err != nil {
+		t.Fatalf("LookPath error: %v", err)
+	}
+	snapshot, err := readNvidiaSMI(context.Background(), path, time.Second)
+	if err != nil {
+		t.Fatalf("readNvidiaSMI error: %v", err)
+	}
+	if len(snapshot.GPUs) != 2 || snapshot.GPUs[1].Name != "NVIDIA RTX A2000" {
+		t.Fatalf("readNvidiaSMI got %+v", snapshot.GPUs)
+	}
+}
+
+func TestReadNvidiaSMIStopsOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, nvidiaSMICommand, "#!/bin/sh\nexec sleep 60\n")
+	path := filepath.Join(dir, nvidiaSMICommand)
+	if err := os.Chmod(path, 0o755); err != nil {
+		t.Fatalf("chmod fake nvidia-smi: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := readNvidiaSMI(ctx, path, time.Minute); err == nil {
+		t.Fatal("expected error from a cancelled query")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("readNvidiaSMI returned after %v, want it to stop on cancel", elapsed)
+	}
+}
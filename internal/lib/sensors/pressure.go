@@ -0,0 +1,191 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pressureResources are the /proc/pressure files sampled, in snapshot order.
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// PressureSnapshot is the Pressure Stall Information of each resource.
+// Kernels without PSI (or booted with psi=0) leave it zero.
+type PressureSnapshot struct {
+	CPU    Pressure
+	Memory Pressure
+	IO     Pressure
+}
+
+// Pressure holds the "some" (at least one task stalled) and "full" (all
+// non-idle tasks stalled) lines of one /proc/pressure file.
+type Pressure struct {
+	Some PressureLine
+	Full PressureLine
+}
+
+// PressureLine has the kernel's running averages plus StallPct, the share
+// of the last sampling interval spent stalled, derived from total.
+type PressureLine struct {
+	Avg10    float64
+	Avg60    float64
+	Avg300   float64
+	StallPct float64
+	total    uint64
+}
+
+type PressureSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	root     Root
+	last     map[string]Pressure
+	lastAt   time.Time
+	snapshot PressureSnapshot
+}
+
+func NewPressureSampler(interval time.Duration, root Root) *PressureSampler {
+	s := &PressureSampler{root: root, last: make(map[string]Pressure)}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *PressureSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		elapsed := now.Sub(s.lastAt)
+
+		// last and lastAt are only touched by this goroutine. A resource
+		// that fails to read drops out of last, so its next reading starts
+		// a fresh baseline instead of spanning two intervals.
+		last := make(map[string]Pressure)
+		var snapshot PressureSnapshot
+		for _, resource := range pressureResources {
+			cur, err := readPressure(s.root.proc("pressure", resource))
+			if err != nil {
+				continue
+			}
+
+			if prev, ok := s.last[resource]; ok {
+				cur.Some.StallPct = stallPct(prev.Some.total, cur.Some.total, elapsed)
+				cur.Full.StallPct = stallPct(prev.Full.total, cur.Full.total, elapsed)
+			}
+			last[resource] = cur
+
+			switch resource {
+			case "cpu":
+				snapshot.CPU = cur
+			case "memory":
+				snapshot.Memory = cur
+			case "io":
+				snapshot.IO = cur
+			}
+		}
+
+		s.last = last
+		s.lastAt = now
+
+		s.mu.Lock()
+		s.snapshot = snapshot
+		s.mu.Unlock()
+	}
+}
+
+func (s *PressureSampler) Snapshot() PressureSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.snapshot
+}
+
+// stallPct converts two total= readings (µs stalled) taken elapsed apart
+// into the percentage of that time spent stalled.
+func stallPct(prev uint64, cur uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	pct := 100.0 * float64(counterDelta(prev, cur)) / float64(elapsed.Microseconds())
+	if pct > 100 {
+		return 100
+	}
+
+	return pct
+}
+
+func readPressure(path string) (Pressure, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Pressure{}, err
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	var pressure Pressure
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		kind, rest, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+
+		line := parsePressureLine(rest)
+		switch kind {
+		case "some":
+			pressure.Some = line
+		case "full":
+			pressure.Full = line
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Pressure{}, err
+	}
+
+	return pressure, nil
+}
+
+// parsePressureLine parses "avg10=0.12 avg60=0.05 avg300=0.01 total=12345".
+func parsePressureLine(s string) PressureLine {
+	var line PressureLine
+	for _, field := range strings.Fields(s) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "avg10":
+			line.Avg10, _ = strconv.ParseFloat(value, 64)
+		case "avg60":
+			line.Avg60, _ = strconv.ParseFloat(value, 64)
+		case "avg300":
+			line.Avg300, _ = strconv.ParseFloat(value, 64)
+		case "total":
+			line.total, _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+
+	return line
+}
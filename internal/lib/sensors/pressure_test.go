@@ -0,0 +1,68 @@
+package sensors
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReadPressure(t *testing.T) {
+	root := Root{Proc: t.TempDir()}
+	writeFixture(t, root.Proc, "pressure/memory", ""+
+		"some avg10=1.53 avg60=0.87 avg300=0.20 total=1000000\n"+
+		"full avg10=0.50 avg60=0.25 avg300=0.05 total=400000\n")
+
+	pressure, err := readPressure(root.proc("pressure", "memory"))
+	if err != nil {
+		t.Fatalf("readPressure error: %v", err)
+	}
+
+	want := Pressure{
+		Some: PressureLine{Avg10: 1.53, Avg60: 0.87, Avg300: 0.20, total: 1000000},
+		Full: PressureLine{Avg10: 0.50, Avg60: 0.25, Avg300: 0.05, total: 400000},
+	}
+	if pressure != want {
+		t.Fatalf("readPressure got %+v, want %+v", pressure, want)
+	}
+}
+
+func TestReadPressureMissingFile(t *testing.T) {
+	root := Root{Proc: t.TempDir()}
+	if _, err := readPressure(root.proc("pressure", "cpu")); err == nil {
+		t.Fatal("expected error when PSI is unavailable")
+	}
+}
+
+func TestStallPct(t *testing.T) {
+	tests := []struct {
+		name string
+		prev uint64
+		cur  uint64
+		want float64
+	}{
+		{name: "quarter of interval", prev: 1000000, cur: 1250000, want: 25},
+		{name: "idle", prev: 1000000, cur: 1000000, want: 0},
+		{name: "counter reset", prev: 1000000, cur: 10, want: 0},
+		{name: "clamped", prev: 0, cur: 2000000, want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stallPct(tt.prev, tt.cur, time.Second)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("stallPct(%d, %d)=%v, want %v", tt.prev, tt.cur, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStallPctUsesElapsed(t *testing.T) {
+	// A missed tick makes the readings two seconds apart; 0.5s stalled is
+	// 25% of that, not 50% of the nominal one-second interval.
+	if got := stallPct(1000000, 1500000, 2*time.Second); math.Abs(got-25) > 1e-9 {
+		t.Fatalf("stallPct over 2s got %v, want 25", got)
+	}
+	if got := stallPct(0, 100, 0); got != 0 {
+		t.Fatalf("stallPct with no elapsed time got %v, want 0", got)
+	}
+}
@@ -0,0 +1,281 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// userHZ is the unit of utime/stime in /proc/<pid>/stat. It is 100 on every
+// architecture Linux ships with a stable ABI for.
+const userHZ = 100
+
+const DefaultProcessTopN = 10
+
+// ProcessSnapshot lists the top N processes by CPU, resident memory and GPU
+// usage. A process can appear in more than one list.
+type ProcessSnapshot struct {
+	ByCPU []Process
+	ByRSS []Process
+	ByGPU []Process
+}
+
+// Process is one process's usage over the last interval. CPUPct is relative
+// to one core, as in top, so a busy multi-threaded process can exceed 100.
+// GPUPct is its busiest DRM engine.
+type Process struct {
+	PID     int
+	Name    string
+	CPUPct  float64
+	RSSMiB  float64
+	GPUPct  float64
+	VRAMMiB float64
+}
+
+// procKey tells a process apart from a later one that reused its PID.
+type procKey struct {
+	PID   int
+	Start uint64
+}
+
+type pidStat struct {
+	Name   string
+	Ticks  uint64
+	Start  uint64
+	RSSKiB uint64
+}
+
+type ProcessSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	root     Root
+	topN     int
+	last     map[procKey]pidStat
+	lastDRM  map[int]map[drmClientKey]drmClient
+	lastAt   time.Time
+	snapshot ProcessSnapshot
+}
+
+// NewProcessSampler walks /proc every interval and keeps the topN processes
+// of each list; topN <= 0 uses DefaultProcessTopN.
+func NewProcessSampler(interval time.Duration, root Root, topN int) *ProcessSampler {
+	if topN <= 0 {
+		topN = DefaultProcessTopN
+	}
+
+	s := &ProcessSampler{root: root, topN: topN}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *ProcessSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		procs := readPIDStats(s.root)
+		drm := readDRMClientsByPID(s.root)
+		now := time.Now()
+
+		s.mu.Lock()
+		if !s.lastAt.IsZero() {
+			processes := processUsage(s.last, procs, s.lastDRM, drm, now.Sub(s.lastAt))
+			s.snapshot = topProcesses(processes, s.topN)
+		}
+		s.last = procs
+		s.lastDRM = drm
+		s.lastAt = now
+		s.mu.Unlock()
+	}
+}
+
+func (s *ProcessSampler) Snapshot() ProcessSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return ProcessSnapshot{
+		ByCPU: append([]Process(nil), s.snapshot.ByCPU...),
+		ByRSS: append([]Process(nil), s.snapshot.ByRSS...),
+		ByGPU: append([]Process(nil), s.snapshot.ByGPU...),
+	}
+}
+
+func processUsage(prev map[procKey]pidStat, cur map[procKey]pidStat, prevDRM map[int]map[drmClientKey]drmClient, curDRM map[int]map[drmClientKey]drmClient, elapsed time.Duration) []Process {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return nil
+	}
+
+	processes := make([]Process, 0, len(cur))
+	for key, stat := range cur {
+		p := Process{
+			PID:    key.PID,
+			Name:   stat.Name,
+			RSSMiB: float64(stat.RSSKiB) / kibPerMiB,
+		}
+		if last, ok := prev[key]; ok {
+			p.CPUPct = 100.0 * float64(counterDelta(last.Ticks, stat.Ticks)) / userHZ / seconds
+		}
+
+		busy := make(map[string]float64)
+		for clientKey, client := range curDRM[key.PID] {
+			p.VRAMMiB += float64(client.VRAMBytes) / bytesPerMiB
+
+			last, ok := prevDRM[key.PID][clientKey]
+			if !ok {
+				continue
+			}
+			for name, usage := range client.Engines {
+				busy[name] += engineBusyPct(last.Engines[name], usage, elapsed)
+			}
+		}
+		for _, pct := range busy {
+			p.GPUPct = max(p.GPUPct, min(pct, 100))
+		}
+
+		processes = append(processes, p)
+	}
+
+	return processes
+}
+
+func topProcesses(processes []Process, n int) ProcessSnapshot {
+	top := func(less func(a, b Process) bool, keep func(Process) bool) []Process {
+		var list []Process
+		for _, p := range processes {
+			if keep(p) {
+				list = append(list, p)
+			}
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if less(list[i], list[j]) {
+				return true
+			}
+			if less(list[j], list[i]) {
+				return false
+			}
+			return list[i].PID < list[j].PID
+		})
+		if len(list) > n {
+			list = list[:n]
+		}
+		return list
+	}
+
+	return ProcessSnapshot{
+		ByCPU: top(func(a, b Process) bool { return a.CPUPct > b.CPUPct }, func(Process) bool { return true }),
+		ByRSS: top(func(a, b Process) bool { return a.RSSMiB > b.RSSMiB }, func(Process) bool { return true }),
+		ByGPU: top(func(a, b Process) bool {
+			if a.GPUPct != b.GPUPct {
+				return a.GPUPct > b.GPUPct
+			}
+			return a.VRAMMiB > b.VRAMMiB
+		}, func(p Process) bool { return p.GPUPct > 0 || p.VRAMMiB > 0 }),
+	}
+}
+
+// readPIDStats reads every /proc/<pid>. Processes that exit while being
+// read are skipped.
+func readPIDStats(root Root) map[procKey]pidStat {
+	procs := make(map[procKey]pidStat)
+
+	dirs, err := filepath.Glob(root.proc("[0-9]*"))
+	if err != nil {
+		return procs
+	}
+
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		stat, err := parsePIDStat(string(raw))
+		if err != nil {
+			continue
+		}
+		stat.RSSKiB = readVmRSS(filepath.Join(dir, "status"))
+
+		procs[procKey{PID: pid, Start: stat.Start}] = stat
+	}
+
+	return procs
+}
+
+// parsePIDStat parses /proc/<pid>/stat. comm is wrapped in parentheses
+// and may itself contain spaces and parentheses, so fields are counted from
+// the last ")".
+func parsePIDStat(content string) (pidStat, error) {
+	open := strings.IndexByte(content, '(')
+	end := strings.LastIndexByte(content, ')')
+	if open < 0 || end < open {
+		return pidStat{}, fmt.Errorf("malformed stat: %q", content)
+	}
+
+	// fields[0] is field 3 (state): utime, stime and starttime are fields
+	// 14, 15 and 22.
+	fields := strings.Fields(content[end+1:])
+	if len(fields) < 20 {
+		return pidStat{}, fmt.Errorf("short stat: %q", content)
+	}
+
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return pidStat{}, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return pidStat{}, err
+	}
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return pidStat{}, err
+	}
+
+	return pidStat{Name: content[open+1 : end], Ticks: utime + stime, Start: start}, nil
+}
+
+// readVmRSS returns VmRSS from /proc/<pid>/status in kB. Kernel threads
+// have no VmRSS line and report 0.
+func readVmRSS(path string) uint64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "VmRSS:") {
+			return parseMemInfoKB(line)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,107 @@
+package sensors
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePIDStat(t *testing.T) {
+	// comm may contain spaces and parentheses.
+	stat, err := parsePIDStat("4242 (Web Content (x)) S 1 4242 4242 0 -1 4194560 1000 0 0 0 1500 250 0 0 20 0 30 0 987654 2147483648 51200 18446744073709551615\n")
+	if err != nil {
+		t.Fatalf("parsePIDStat error: %v", err)
+	}
+
+	want := pidStat{Name: "Web Content (x)", Ticks: 1750, Start: 987654}
+	if stat != want {
+		t.Fatalf("parsePIDStat got %+v, want %+v", stat, want)
+	}
+
+	if _, err := parsePIDStat("4242 (short) S 1 2 3"); err == nil {
+		t.Fatal("expected error for a truncated stat line")
+	}
+}
+
+func TestReadPIDStats(t *testing.T) {
+	root := Root{Proc: t.TempDir()}
+	writeFixture(t, root.Proc, "1/stat", "1 (systemd) S 0 1 1 0 -1 4194560 0 0 0 0 10 20 0 0 20 0 1 0 5 0 0 0\n")
+	writeFixture(t, root.Proc, "1/status", "Name:\tsystemd\nVmRSS:\t   12288 kB\n")
+	writeFixture(t, root.Proc, "2/stat", "2 (kthreadd) S 0 0 0 0 -1 2129984 0 0 0 0 0 1 0 0 20 0 1 0 5 0 0 0\n")
+	writeFixture(t, root.Proc, "2/status", "Name:\tkthreadd\n")
+	writeFixture(t, root.Proc, "self/stat", "ignored")
+
+	procs := readPIDStats(root)
+	if len(procs) != 2 {
+		t.Fatalf("readPIDStats got %d processes, want 2: %+v", len(procs), procs)
+	}
+	if got := procs[procKey{PID: 1, Start: 5}]; got.Name != "systemd" || got.Ticks != 30 || got.RSSKiB != 12288 {
+		t.Fatalf("systemd got %+v", got)
+	}
+	if got := procs[procKey{PID: 2, Start: 5}]; got.RSSKiB != 0 {
+		t.Fatalf("kernel thread should have no RSS, got %+v", got)
+	}
+}
+
+func TestReadDRMClientsByPID(t *testing.T) {
+	root := Root{Proc: t.TempDir()}
+	writeFixture(t, root.Proc, "300/fdinfo/7", "drm-driver:\tamdgpu\ndrm-client-id:\t12\ndrm-pdev:\t0000:03:00.0\ndrm-engine-gfx:\t5000 ns\ndrm-memory-vram:\t524288 KiB\ndrm-memory-gtt:\t2048 KiB\n")
+	if err := os.MkdirAll(filepath.Join(root.Proc, "300", "fd"), 0o755); err != nil {
+		t.Fatalf("mkdir fd: %v", err)
+	}
+	if err := os.Symlink("/dev/dri/renderD128", filepath.Join(root.Proc, "300", "fd", "7")); err != nil {
+		t.Fatalf("symlink fd: %v", err)
+	}
+
+	byPID := readDRMClientsByPID(root)
+	client, ok := byPID[300][drmClientKey{PDev: "0000:03:00.0", ID: "12"}]
+	if !ok {
+		t.Fatalf("readDRMClientsByPID got %+v", byPID)
+	}
+	if client.VRAMBytes != 512<<20 || client.Engines["gfx"].BusyNs != 5000 {
+		t.Fatalf("client got %+v", client)
+	}
+}
+
+func TestProcessUsageAndTop(t *testing.T) {
+	game := procKey{PID: 300, Start: 100}
+	shell := procKey{PID: 200, Start: 50}
+	reused := procKey{PID: 400, Start: 900}
+
+	prev := map[procKey]pidStat{
+		game:                  {Name: "game", Ticks: 1000},
+		shell:                 {Name: "bash", Ticks: 10},
+		{PID: 400, Start: 10}: {Name: "old", Ticks: 5000},
+	}
+	cur := map[procKey]pidStat{
+		game:   {Name: "game", Ticks: 1150, RSSKiB: 4 << 20},
+		shell:  {Name: "bash", Ticks: 12, RSSKiB: 8192},
+		reused: {Name: "new", Ticks: 5100, RSSKiB: 1024},
+	}
+
+	clientKey := drmClientKey{PDev: "0000:03:00.0", ID: "12"}
+	prevDRM := map[int]map[drmClientKey]drmClient{300: {clientKey: {Engines: map[string]drmEngineUsage{"gfx": {BusyNs: 0}}}}}
+	curDRM := map[int]map[drmClientKey]drmClient{300: {clientKey: {
+		Engines:   map[string]drmEngineUsage{"gfx": {BusyNs: 900_000_000}},
+		VRAMBytes: 2 << 30,
+	}}}
+
+	processes := processUsage(prev, cur, prevDRM, curDRM, time.Second)
+	top := topProcesses(processes, 2)
+
+	if len(top.ByCPU) != 2 || top.ByCPU[0].PID != 300 || math.Abs(top.ByCPU[0].CPUPct-150) > 1e-9 {
+		t.Fatalf("ByCPU got %+v", top.ByCPU)
+	}
+	// PID 400 was reused, so its old ticks must not count.
+	if top.ByCPU[1].PID != 200 || math.Abs(top.ByCPU[1].CPUPct-2) > 1e-9 {
+		t.Fatalf("ByCPU second got %+v", top.ByCPU[1])
+	}
+	if len(top.ByRSS) != 2 || top.ByRSS[0].RSSMiB != 4096 || top.ByRSS[1].RSSMiB != 8 {
+		t.Fatalf("ByRSS got %+v", top.ByRSS)
+	}
+	if len(top.ByGPU) != 1 || math.Abs(top.ByGPU[0].GPUPct-90) > 1e-9 || top.ByGPU[0].VRAMMiB != 2048 {
+		t.Fatalf("ByGPU got %+v", top.ByGPU)
+	}
+}
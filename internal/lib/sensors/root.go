@@ -0,0 +1,38 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import "path/filepath"
+
+const (
+	defaultProcRoot = "/proc"
+	defaultSysRoot  = "/sys"
+)
+
+// Root locates the procfs and sysfs trees the samplers read from.
+//
+// Empty fields fall back to the host's /proc and /sys, so the zero value reads
+// the live system. Point them elsewhere to run against a bind-mounted host
+// tree (e.g. inside a container) or against fixture trees in tests.
+type Root struct {
+	Proc string
+	Sys  string
+}
+
+func (r Root) proc(elem ...string) string {
+	base := r.Proc
+	if base == "" {
+		base = defaultProcRoot
+	}
+
+	return filepath.Join(append([]string{base}, elem...)...)
+}
+
+func (r Root) sys(elem ...string) string {
+	base := r.Sys
+	if base == "" {
+		base = defaultSysRoot
+	}
+
+	return filepath.Join(append([]string{base}, elem...)...)
+}
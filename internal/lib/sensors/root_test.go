@@ -0,0 +1,85 @@
+package sensors
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFixture(t *testing.T, base string, rel string, content string) {
+	t.Helper()
+
+	path := filepath.Join(base, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestRootDefaultsToHostTrees(t *testing.T) {
+	var root Root
+
+	if got := root.proc("stat"); got != "/proc/stat" {
+		t.Fatalf("proc path got %q, want /proc/stat", got)
+	}
+	if got := root.sys("class", "drm"); got != "/sys/class/drm" {
+		t.Fatalf("sys path got %q, want /sys/class/drm", got)
+	}
+}
+
+func TestRootFixtureTree(t *testing.T) {
+	root := Root{Proc: t.TempDir(), Sys: t.TempDir()}
+
+	writeFixture(t, root.Proc, "stat", "cpu  100 0 50 800 50 0 0 0 0 0\ncpu0 100 0 50 800 50 0 0 0 0 0\n")
+	writeFixture(t, root.Proc, "meminfo", "MemTotal:       16777216 kB\nMemFree:         1048576 kB\nMemAvailable:    4194304 kB\n")
+	writeFixture(t, root.Sys, "class/drm/card1/device/gpu_busy_percent", "42\n")
+	writeFixture(t, root.Sys, "class/drm/card1/device/mem_info_vram_used", "1073741824\n")
+	writeFixture(t, root.Sys, "class/drm/card1/device/mem_info_vram_total", "4294967296\n")
+
+	stat, err := readProcStat(root.proc("stat"))
+	if err != nil {
+		t.Fatalf("readProcStat error: %v", err)
+	}
+	if stat.Total.Idle != 800 || stat.Total.total() != 1000 || len(stat.Cores) != 1 {
+		t.Fatalf("readProcStat got %+v, want idle=800 total=1000 and one core", stat)
+	}
+
+	ram, err := readMemorySnapshot(root)
+	if err != nil {
+		t.Fatalf("readMemorySnapshot error: %v", err)
+	}
+	if math.Abs(ram.TotalGiB-16) > 1e-9 || math.Abs(ram.UsedGiB-12) > 1e-9 {
+		t.Fatalf("readMemorySnapshot got %+v", ram)
+	}
+
+	busyCards := detectGPUBusyCards(root)
+	if len(busyCards) != 1 || gpuBusyPath(busyCards[0]) != filepath.Join(root.Sys, "class/drm/card1/device/gpu_busy_percent") {
+		t.Fatalf("detectGPUBusyCards got %+v", busyCards)
+	}
+
+	vramCards := detectVRAMCards(root)
+	if len(vramCards) != 1 {
+		t.Fatalf("detectVRAMCards got %+v", vramCards)
+	}
+	vram, err := readVRAM(vramPaths(vramCards[0]))
+	if err != nil {
+		t.Fatalf("readVRAM error: %v", err)
+	}
+	if math.Abs(vram.UsedPct-25) > 1e-9 {
+		t.Fatalf("VRAM UsedPct got %v, want 25", vram.UsedPct)
+	}
+
+	writeFixture(t, root.Sys, "class/powercap/intel-rapl:0/name", "package-0\n")
+	writeFixture(t, root.Sys, "class/powercap/intel-rapl:0/energy_uj", "1000\n")
+	writeFixture(t, root.Sys, "class/powercap/intel-rapl:0/max_energy_range_uj", "262143328850\n")
+	domains, err := detectRAPLDomains(root)
+	if err != nil {
+		t.Fatalf("detectRAPLDomains error: %v", err)
+	}
+	if len(domains) != 1 || domains[0].energyPath != filepath.Join(root.Sys, "class/powercap/intel-rapl:0/energy_uj") {
+		t.Fatalf("detectRAPLDomains got %+v", domains)
+	}
+}
@@ -0,0 +1,100 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Sampler is implemented by every New*Sampler. Constructors only set a
+// sampler up; Start launches its goroutines, which run until ctx is
+// cancelled or Stop is called.
+type Sampler interface {
+	Start(ctx context.Context)
+	Stop()
+}
+
+// Every New*Sampler returns a Sampler.
+var (
+	_ Sampler = (*CPUBusySampler)(nil)
+	_ Sampler = (*CPUPowerSampler)(nil)
+	_ Sampler = (*CPUFreqSampler)(nil)
+	_ Sampler = (*CPUThrottleSampler)(nil)
+	_ Sampler = (*SystemRAMSampler)(nil)
+	_ Sampler = (*LmSensorsSampler)(nil)
+	_ Sampler = (*FanSampler)(nil)
+	_ Sampler = (*GPUBusySampler)(nil)
+	_ Sampler = (*GPUVRAMSampler)(nil)
+	_ Sampler = (*GPUClockSampler)(nil)
+	_ Sampler = (*AMDGPUMetricsSampler)(nil)
+	_ Sampler = (*NvidiaSMISampler)(nil)
+	_ Sampler = (*IntelGPUSampler)(nil)
+	_ Sampler = (*DiskIOSampler)(nil)
+	_ Sampler = (*NetIOSampler)(nil)
+	_ Sampler = (*StorageSampler)(nil)
+	_ Sampler = (*FilesystemSampler)(nil)
+	_ Sampler = (*PressureSampler)(nil)
+	_ Sampler = (*ProcessSampler)(nil)
+	_ Sampler = (*CgroupSampler)(nil)
+	_ Sampler = (*BatterySampler)(nil)
+	_ Sampler = (*ThermalSampler)(nil)
+)
+
+// sampleLoop is one goroutine of a sampler, ticking every interval.
+type sampleLoop struct {
+	interval time.Duration
+	run      func(ctx context.Context, interval time.Duration)
+}
+
+// lifecycle implements Sampler for the samplers that embed it. Constructors
+// register their loops with onStart; samplers that found nothing to read
+// register none, and Start is then a no-op.
+type lifecycle struct {
+	lifeMu sync.Mutex
+	loops  []sampleLoop
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (l *lifecycle) onStart(interval time.Duration, run func(ctx context.Context, interval time.Duration)) {
+	l.lifeMu.Lock()
+	defer l.lifeMu.Unlock()
+
+	l.loops = append(l.loops, sampleLoop{interval: interval, run: run})
+}
+
+// Start launches the registered loops. Calling it on a running sampler does
+// nothing; after Stop it starts again, keeping the previous readings.
+func (l *lifecycle) Start(ctx context.Context) {
+	l.lifeMu.Lock()
+	defer l.lifeMu.Unlock()
+
+	if l.cancel != nil {
+		return
+	}
+
+	ctx, l.cancel = context.WithCancel(ctx)
+	for _, loop := range l.loops {
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			loop.run(ctx, loop.interval)
+		}()
+	}
+}
+
+// Stop cancels the loops and waits for them to return.
+func (l *lifecycle) Stop() {
+	l.lifeMu.Lock()
+	defer l.lifeMu.Unlock()
+
+	if l.cancel == nil {
+		return
+	}
+
+	l.cancel()
+	l.cancel = nil
+	l.wg.Wait()
+}
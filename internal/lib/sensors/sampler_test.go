@@ -0,0 +1,93 @@
+package sensors
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLifecycleStartStop(t *testing.T) {
+	var l lifecycle
+	var ticks atomic.Int64
+	l.onStart(time.Millisecond, func(ctx context.Context, interval time.Duration) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			ticks.Add(1)
+		}
+	})
+
+	l.Start(context.Background())
+	l.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for ticks.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if ticks.Load() < 3 {
+		t.Fatalf("loop ticked %d times, want at least 3", ticks.Load())
+	}
+
+	// Stop waits for the loop, so no tick can land after it returns.
+	l.Stop()
+	stopped := ticks.Load()
+	time.Sleep(10 * time.Millisecond)
+	if got := ticks.Load(); got != stopped {
+		t.Fatalf("loop ticked after Stop: %d -> %d", stopped, got)
+	}
+
+	l.Stop()
+}
+
+func TestLifecycleStopsOnContextCancel(t *testing.T) {
+	var l lifecycle
+	done := make(chan struct{})
+	l.onStart(time.Hour, func(ctx context.Context, _ time.Duration) {
+		<-ctx.Done()
+		close(done)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.Start(ctx)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("loop did not return after its context was cancelled")
+	}
+	l.Stop()
+}
+
+func TestLifecycleWithoutLoops(t *testing.T) {
+	var l lifecycle
+	l.Start(context.Background())
+	l.Stop()
+}
+
+func TestSamplerDoesNotRunUntilStarted(t *testing.T) {
+	root := Root{Proc: t.TempDir()}
+	writeFixture(t, root.Proc, "pressure/cpu", "some avg10=1.00 avg60=0.00 avg300=0.00 total=10\n")
+
+	s := NewPressureSampler(time.Millisecond, root)
+	time.Sleep(10 * time.Millisecond)
+	if got := s.Snapshot(); got != (PressureSnapshot{}) {
+		t.Fatalf("sampler ran before Start: %+v", got)
+	}
+
+	s.Start(context.Background())
+	defer s.Stop()
+	deadline := time.Now().Add(time.Second)
+	for s.Snapshot().CPU.Some.Avg10 == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := s.Snapshot().CPU.Some.Avg10; got != 1 {
+		t.Fatalf("started sampler cpu avg10 got %v, want 1", got)
+	}
+}
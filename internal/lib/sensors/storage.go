@@ -0,0 +1,318 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	smartctlCommand = "smartctl"
+	// smartctlInterval is how often SMART data is refreshed. It changes
+	// slowly, so it is not polled on every tick.
+	smartctlInterval = time.Minute
+	smartctlTimeout  = 10 * time.Second
+	// smartctlStandbyStatus is the exit status asked of `smartctl -n
+	// standby` when it skips a drive that is spun down. smartctl's own
+	// default (2) is shared with "device open failed".
+	smartctlStandbyStatus = 255
+)
+
+// errDriveStandby is returned by readSmartctl for a drive in standby,
+// which is left asleep and keeps its previous health reading.
+var errDriveStandby = errors.New("drive is in standby")
+
+// nvmeCriticalWarningTemp is the "temperature above threshold" bit of the
+// NVMe SMART critical_warning field.
+const nvmeCriticalWarningTemp = 1 << 1
+
+const (
+	DriveKindNVMe = "nvme"
+	DriveKindSATA = "sata"
+)
+
+type StorageSnapshot struct {
+	Drives []Drive
+}
+
+// Drive is the temperature and health of one NVMe controller or SATA/SAS
+// disk. Temperatures come from the nvme and drivetemp hwmon chips; the
+// health fields are only filled when SMART is true, i.e. the smartctl
+// backend is enabled and could read the drive.
+type Drive struct {
+	Name  string
+	Kind  string
+	Model string
+
+	// HasTemp is set when the drive has a temperature sensor, in hwmon or
+	// in its SMART report; TempC is 0 otherwise.
+	TempC     float64
+	HasTemp   bool
+	TempWarnC float64
+	TempCritC float64
+	// Throttling is set while the drive is at or above its warning
+	// temperature, which is where NVMe controllers start to throttle.
+	Throttling bool
+
+	SMART                      bool
+	HealthPassed               bool
+	PercentageUsed             float64
+	AvailableSparePct          float64
+	AvailableSpareThresholdPct float64
+	MediaErrors                uint64
+	CriticalWarning            uint64
+	WarningTempMinutes         uint64
+}
+
+type driveHealth struct {
+	Model                      string
+	HealthPassed               bool
+	TempC                      float64
+	HasTemp                    bool
+	PercentageUsed             float64
+	AvailableSparePct          float64
+	AvailableSpareThresholdPct float64
+	MediaErrors                uint64
+	CriticalWarning            uint64
+	WarningTempMinutes         uint64
+}
+
+type StorageSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	root     Root
+	smartctl string
+	health   map[string]driveHealth
+	snapshot StorageSnapshot
+}
+
+// NewStorageSampler reads drive temperatures from sysfs. With smartctl set
+// it also polls `smartctl -j` for SMART health, which usually needs root.
+func NewStorageSampler(interval time.Duration, root Root, smartctl bool) *StorageSampler {
+	s := &StorageSampler{root: root, health: make(map[string]driveHealth)}
+	if smartctl {
+		path, err := exec.LookPath(smartctlCommand)
+		if err != nil {
+			log.Printf("warning: smartctl backend unavailable: %v", err)
+		} else {
+			s.smartctl = path
+			s.onStart(smartctlInterval, s.runSmartctl)
+		}
+	}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *StorageSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		drives := readDrives(s.root)
+
+		s.mu.Lock()
+		for i := range drives {
+			if health, ok := s.health[drives[i].Name]; ok {
+				applyDriveHealth(&drives[i], health)
+			}
+		}
+		s.snapshot = StorageSnapshot{Drives: drives}
+		s.mu.Unlock()
+	}
+}
+
+func (s *StorageSampler) runSmartctl(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, drive := range readDrives(s.root) {
+			health, err := readSmartctl(ctx, s.smartctl, drive.Name)
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, errDriveStandby) {
+				continue
+			}
+			if err != nil {
+				s.mu.Lock()
+				delete(s.health, drive.Name)
+				s.mu.Unlock()
+				continue
+			}
+
+			s.mu.Lock()
+			s.health[drive.Name] = health
+			s.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *StorageSampler) Snapshot() StorageSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return StorageSnapshot{Drives: append([]Drive(nil), s.snapshot.Drives...)}
+}
+
+// readDrives finds NVMe controllers under /sys/class/nvme and SCSI disks
+// with a drivetemp hwmon chip under /sys/block, and reads their
+// temperatures.
+func readDrives(root Root) []Drive {
+	var drives []Drive
+
+	controllers, _ := filepath.Glob(root.sys("class", "nvme", "nvme*"))
+	for _, dir := range controllers {
+		drives = append(drives, readDrive(filepath.Base(dir), DriveKindNVMe, dir, filepath.Join(dir, "hwmon*")))
+	}
+
+	disks, _ := filepath.Glob(root.sys("block", "sd*"))
+	for _, dir := range disks {
+		device := filepath.Join(dir, "device")
+		chips, _ := filepath.Glob(filepath.Join(device, "hwmon", "hwmon*"))
+		if len(chips) == 0 {
+			continue
+		}
+		drives = append(drives, readDrive(filepath.Base(dir), DriveKindSATA, device, filepath.Join(device, "hwmon", "hwmon*")))
+	}
+
+	sort.Slice(drives, func(i, j int) bool {
+		return naturalLess(drives[i].Name, drives[j].Name)
+	})
+
+	return drives
+}
+
+func readDrive(name string, kind string, deviceDir string, hwmonGlob string) Drive {
+	drive := Drive{Name: name, Kind: kind}
+	drive.Model, _ = readTrimmedFile(filepath.Join(deviceDir, "model"))
+
+	chips, _ := filepath.Glob(hwmonGlob)
+	if len(chips) == 0 {
+		return drive
+	}
+	chip, err := readHwmonChip(chips[0])
+	if err != nil {
+		return drive
+	}
+
+	// temp1 is the NVMe composite temperature and the only drivetemp
+	// channel; temp1_max is the NVMe warning threshold (WCTEMP).
+	for _, input := range chip.Inputs {
+		if input.Kind == "temp" && input.Index == 1 {
+			drive.TempC = input.Value
+			drive.HasTemp = true
+		}
+	}
+	drive.TempWarnC = readUintAsFloat(filepath.Join(chip.Dir, "temp1_max")) / 1_000.0
+	drive.TempCritC = readUintAsFloat(filepath.Join(chip.Dir, "temp1_crit")) / 1_000.0
+	drive.Throttling = drive.TempWarnC > 0 && drive.TempC >= drive.TempWarnC
+
+	return drive
+}
+
+func applyDriveHealth(drive *Drive, health driveHealth) {
+	drive.SMART = true
+	drive.HealthPassed = health.HealthPassed
+	drive.PercentageUsed = health.PercentageUsed
+	drive.AvailableSparePct = health.AvailableSparePct
+	drive.AvailableSpareThresholdPct = health.AvailableSpareThresholdPct
+	drive.MediaErrors = health.MediaErrors
+	drive.CriticalWarning = health.CriticalWarning
+	drive.WarningTempMinutes = health.WarningTempMinutes
+	if drive.Model == "" {
+		drive.Model = health.Model
+	}
+	if !drive.HasTemp && health.HasTemp {
+		drive.TempC = health.TempC
+		drive.HasTemp = true
+	}
+	if health.CriticalWarning&nvmeCriticalWarningTemp != 0 {
+		drive.Throttling = true
+	}
+}
+
+// readSmartctl runs `smartctl -j` against /dev/<name>. smartctl reports
+// drive problems through its exit status, so a non-zero exit still carries
+// a usable report unless the device could not be opened. `-n standby`
+// keeps smartctl from spinning up a sleeping disk; it then exits with
+// smartctlStandbyStatus and readSmartctl returns errDriveStandby. The run
+// is killed after smartctlTimeout or once ctx is cancelled.
+func readSmartctl(ctx context.Context, path string, name string) (driveHealth, error) {
+	ctx, cancel := context.WithTimeout(ctx, smartctlTimeout)
+	defer cancel()
+
+	standby := "standby," + strconv.Itoa(smartctlStandbyStatus)
+	output, err := exec.CommandContext(ctx, path, "-j", "-n", standby, "-i", "-H", "-A", "/dev/"+name).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == smartctlStandbyStatus {
+		return driveHealth{}, errDriveStandby
+	}
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode()&0b11 != 0) {
+		return driveHealth{}, err
+	}
+
+	return parseSmartctl(output)
+}
+
+func parseSmartctl(output []byte) (driveHealth, error) {
+	var report struct {
+		ModelName   string `json:"model_name"`
+		SmartStatus struct {
+			Passed bool `json:"passed"`
+		} `json:"smart_status"`
+		Temperature struct {
+			Current *float64 `json:"current"`
+		} `json:"temperature"`
+		NVMeHealth struct {
+			CriticalWarning         uint64  `json:"critical_warning"`
+			AvailableSpare          float64 `json:"available_spare"`
+			AvailableSpareThreshold float64 `json:"available_spare_threshold"`
+			PercentageUsed          float64 `json:"percentage_used"`
+			MediaErrors             uint64  `json:"media_errors"`
+			WarningTempTime         uint64  `json:"warning_temp_time"`
+		} `json:"nvme_smart_health_information_log"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return driveHealth{}, err
+	}
+
+	health := driveHealth{
+		Model:                      report.ModelName,
+		HealthPassed:               report.SmartStatus.Passed,
+		PercentageUsed:             report.NVMeHealth.PercentageUsed,
+		AvailableSparePct:          report.NVMeHealth.AvailableSpare,
+		AvailableSpareThresholdPct: report.NVMeHealth.AvailableSpareThreshold,
+		MediaErrors:                report.NVMeHealth.MediaErrors,
+		CriticalWarning:            report.NVMeHealth.CriticalWarning,
+		WarningTempMinutes:         report.NVMeHealth.WarningTempTime,
+	}
+	if report.Temperature.Current != nil {
+		health.TempC, health.HasTemp = *report.Temperature.Current, true
+	}
+
+	return health, nil
+}
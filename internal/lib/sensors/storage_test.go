@@ -0,0 +1,163 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const smartctlNVMeFixture = `{
+  "smartctl": {"exit_status": 0},
+  "model_name": "Samsung SSD 990 PRO 2TB",
+  "smart_status": {"passed": true, "nvme": {"value": 2}},
+  "nvme_smart_health_information_log": {
+    "critical_warning": 2,
+    "temperature": 84,
+    "available_spare": 100,
+    "available_spare_threshold": 10,
+    "percentage_used": 3,
+    "media_errors": 0,
+    "warning_temp_time": 17
+  },
+  "temperature": {"current": 84}
+}`
+
+func TestReadDrives(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeFixture(t, root.Sys, "class/nvme/nvme0/model", "Samsung SSD 990 PRO 2TB\n")
+	writeFixture(t, root.Sys, "class/nvme/nvme0/hwmon3/name", "nvme\n")
+	writeFixture(t, root.Sys, "class/nvme/nvme0/hwmon3/temp1_input", "84850\n")
+	writeFixture(t, root.Sys, "class/nvme/nvme0/hwmon3/temp1_label", "Composite\n")
+	writeFixture(t, root.Sys, "class/nvme/nvme0/hwmon3/temp1_max", "81850\n")
+	writeFixture(t, root.Sys, "class/nvme/nvme0/hwmon3/temp1_crit", "84850\n")
+	writeFixture(t, root.Sys, "class/nvme/nvme0/hwmon3/temp2_input", "90850\n")
+	writeFixture(t, root.Sys, "class/nvme/nvme0/hwmon3/temp2_label", "Sensor 1\n")
+	writeFixture(t, root.Sys, "block/sda/device/model", "ST4000DM004-2CV1\n")
+	writeFixture(t, root.Sys, "block/sda/device/hwmon/hwmon5/name", "drivetemp\n")
+	writeFixture(t, root.Sys, "block/sda/device/hwmon/hwmon5/temp1_input", "36000\n")
+	// No drivetemp chip, so sdb is not reported.
+	writeFixture(t, root.Sys, "block/sdb/device/model", "USB Flash\n")
+
+	drives := readDrives(root)
+	if len(drives) != 2 {
+		t.Fatalf("expected 2 drives, got %+v", drives)
+	}
+
+	nvme := drives[0]
+	want := Drive{Name: "nvme0", Kind: DriveKindNVMe, Model: "Samsung SSD 990 PRO 2TB", TempC: 84.85, HasTemp: true, TempWarnC: 81.85, TempCritC: 84.85, Throttling: true}
+	if nvme != want {
+		t.Fatalf("nvme0 got %+v, want %+v", nvme, want)
+	}
+
+	sata := drives[1]
+	want = Drive{Name: "sda", Kind: DriveKindSATA, Model: "ST4000DM004-2CV1", TempC: 36, HasTemp: true}
+	if sata != want {
+		t.Fatalf("sda got %+v, want %+v", sata, want)
+	}
+}
+
+func TestParseSmartctlNVMe(t *testing.T) {
+	health, err := parseSmartctl([]byte(smartctlNVMeFixture))
+	if err != nil {
+		t.Fatalf("parseSmartctl error: %v", err)
+	}
+
+	want := driveHealth{
+		Model:                      "Samsung SSD 990 PRO 2TB",
+		HealthPassed:               true,
+		TempC:                      84,
+		HasTemp:                    true,
+		PercentageUsed:             3,
+		AvailableSparePct:          100,
+		AvailableSpareThresholdPct: 10,
+		CriticalWarning:            2,
+		WarningTempMinutes:         17,
+	}
+	if health != want {
+		t.Fatalf("health got %+v, want %+v", health, want)
+	}
+
+	drive := Drive{Name: "nvme0", Kind: DriveKindNVMe, TempC: 70, HasTemp: true}
+	applyDriveHealth(&drive, health)
+	if !drive.SMART || !drive.Throttling || drive.TempC != 70 || drive.Model != "Samsung SSD 990 PRO 2TB" {
+		t.Fatalf("applyDriveHealth got %+v", drive)
+	}
+
+	// Without a hwmon sensor the SMART temperature fills in.
+	drive = Drive{Name: "sdb", Kind: DriveKindSATA}
+	applyDriveHealth(&drive, health)
+	if !drive.HasTemp || drive.TempC != 84 {
+		t.Fatalf("applyDriveHealth without hwmon temp got %+v", drive)
+	}
+}
+
+func TestParseSmartctlWithoutTemperature(t *testing.T) {
+	health, err := parseSmartctl([]byte(`{"model_name": "USB Flash", "smart_status": {"passed": true}}`))
+	if err != nil {
+		t.Fatalf("parseSmartctl error: %v", err)
+	}
+	if health.HasTemp || health.TempC != 0 {
+		t.Fatalf("health without a temperature got %+v", health)
+	}
+
+	drive := Drive{Name: "sdb", Kind: DriveKindSATA}
+	applyDriveHealth(&drive, health)
+	if drive.HasTemp {
+		t.Fatalf("drive without a temperature sensor got %+v", drive)
+	}
+}
+
+func TestParseSmartctlRejectsInvalidJSON(t *testing.T) {
+	if _, err := parseSmartctl([]byte("smartctl: command not found")); err == nil {
+		t.Fatal("expected error for non-JSON output")
+	}
+}
+
+// writeFakeSmartctl writes a shell script standing in for smartctl that
+// records its arguments, prints stdout and exits with status.
+func writeFakeSmartctl(t *testing.T, stdout string, status int) (path string, argsFile string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path = filepath.Join(dir, "smartctl")
+	argsFile = filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\ncat <<'EOF'\n" + stdout + "\nEOF\nexit " + strconv.Itoa(status) + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake smartctl: %v", err)
+	}
+
+	return path, argsFile
+}
+
+func TestReadSmartctlSkipsStandbyDrive(t *testing.T) {
+	path, argsFile := writeFakeSmartctl(t, "{}", smartctlStandbyStatus)
+
+	_, err := readSmartctl(context.Background(), path, "sda")
+	if !errors.Is(err, errDriveStandby) {
+		t.Fatalf("readSmartctl error got %v, want errDriveStandby", err)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("read args: %v", err)
+	}
+	if got, want := string(args), "-j -n standby,255 -i -H -A /dev/sda\n"; got != want {
+		t.Fatalf("smartctl args got %q, want %q", got, want)
+	}
+}
+
+func TestReadSmartctlKeepsReportOnWarningStatus(t *testing.T) {
+	// Bit 3 ("disk failing") still comes with a full report.
+	path, _ := writeFakeSmartctl(t, smartctlNVMeFixture, 8)
+
+	health, err := readSmartctl(context.Background(), path, "nvme0")
+	if err != nil {
+		t.Fatalf("readSmartctl error: %v", err)
+	}
+	if health.PercentageUsed != 3 {
+		t.Fatalf("readSmartctl health got %+v", health)
+	}
+}
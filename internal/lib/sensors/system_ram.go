@@ -4,39 +4,88 @@ package sensors
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+const (
+	kibPerMiB   = 1024.0
+	kibPerGiB   = 1024.0 * 1024.0
+	bytesPerMiB = 1024.0 * 1024.0
+	bytesPerGiB = 1024.0 * 1024.0 * 1024.0
+)
+
+// SystemRAMSnapshot is the memory breakdown from /proc/meminfo. Sizes are
+// binary units (GiB = 2^30 bytes), matching what the kernel reports in kB.
 type SystemRAMSnapshot struct {
-	TotalGB float64
-	UsedGB  float64
-	AvailGB float64
-	UsedPct float64
+	TotalGiB float64
+	UsedGiB  float64
+	AvailGiB float64
+	UsedPct  float64
+
+	FreeGiB      float64
+	BuffersGiB   float64
+	CachedGiB    float64
+	ShmemGiB     float64
+	DirtyMiB     float64
+	WritebackMiB float64
+
+	SwapTotalGiB float64
+	SwapUsedGiB  float64
+	SwapUsedPct  float64
+
+	Zram ZramSnapshot
+
+	HugePagesTotal   uint64
+	HugePagesFree    uint64
+	HugePageSizeKiB  uint64
+	HugePagesUsedGiB float64
+}
+
+// ZramSnapshot sums mm_stat across every zram device. OrigGiB is the data
+// stored, ComprGiB its compressed size and MemUsedGiB the memory zram
+// actually holds, including allocator overhead.
+type ZramSnapshot struct {
+	Devices    int
+	OrigGiB    float64
+	ComprGiB   float64
+	MemUsedGiB float64
+	Ratio      float64
 }
 
 type SystemRAMSampler struct {
+	lifecycle
+
 	mu       sync.RWMutex
 	snapshot SystemRAMSnapshot
+	root     Root
 }
 
-func NewSystemRAMSampler(interval time.Duration) *SystemRAMSampler {
-	s := &SystemRAMSampler{}
-	go s.run(interval)
+func NewSystemRAMSampler(interval time.Duration, root Root) *SystemRAMSampler {
+	s := &SystemRAMSampler{root: root}
+	s.onStart(interval, s.run)
 
 	return s
 }
 
-func (s *SystemRAMSampler) run(interval time.Duration) {
+func (s *SystemRAMSampler) run(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		snapshot, err := readMemorySnapshot()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot, err := readMemorySnapshot(s.root)
 		if err != nil {
 			continue
 		}
@@ -53,33 +102,53 @@ func (s *SystemRAMSampler) Snapshot() (SystemRAMSnapshot, error) {
 	return s.snapshot, nil
 }
 
-func readMemorySnapshot() (SystemRAMSnapshot, error) {
-	totalKB, availKB, err := readMemInfo()
+func readMemorySnapshot(root Root) (SystemRAMSnapshot, error) {
+	info, err := readMemInfo(root.proc("meminfo"))
 	if err != nil {
 		return SystemRAMSnapshot{}, err
 	}
 
-	totalGB := float64(totalKB) / (1024.0 * 1024.0)
-	availGB := float64(availKB) / (1024.0 * 1024.0)
-	usedGB := totalGB - availGB
-
-	usedPct := 0.0
-	if totalGB > 0 {
-		usedPct = 100.0 * usedGB / totalGB
+	gib := func(key string) float64 { return float64(info[key]) / kibPerGiB }
+	mib := func(key string) float64 { return float64(info[key]) / kibPerMiB }
+
+	snapshot := SystemRAMSnapshot{
+		TotalGiB:     gib("MemTotal"),
+		AvailGiB:     gib("MemAvailable"),
+		FreeGiB:      gib("MemFree"),
+		BuffersGiB:   gib("Buffers"),
+		CachedGiB:    gib("Cached"),
+		ShmemGiB:     gib("Shmem"),
+		DirtyMiB:     mib("Dirty"),
+		WritebackMiB: mib("Writeback"),
+		SwapTotalGiB: gib("SwapTotal"),
+		SwapUsedGiB:  gib("SwapTotal") - gib("SwapFree"),
+
+		HugePagesTotal:  info["HugePages_Total"],
+		HugePagesFree:   info["HugePages_Free"],
+		HugePageSizeKiB: info["Hugepagesize"],
+	}
+	snapshot.UsedGiB = snapshot.TotalGiB - snapshot.AvailGiB
+	if snapshot.TotalGiB > 0 {
+		snapshot.UsedPct = 100.0 * snapshot.UsedGiB / snapshot.TotalGiB
+	}
+	if snapshot.SwapTotalGiB > 0 {
+		snapshot.SwapUsedPct = 100.0 * snapshot.SwapUsedGiB / snapshot.SwapTotalGiB
+	}
+	if snapshot.HugePagesTotal > snapshot.HugePagesFree {
+		usedKiB := (snapshot.HugePagesTotal - snapshot.HugePagesFree) * snapshot.HugePageSizeKiB
+		snapshot.HugePagesUsedGiB = float64(usedKiB) / kibPerGiB
 	}
+	snapshot.Zram = readZram(root)
 
-	return SystemRAMSnapshot{
-		TotalGB: totalGB,
-		UsedGB:  usedGB,
-		AvailGB: availGB,
-		UsedPct: usedPct,
-	}, nil
+	return snapshot, nil
 }
 
-func readMemInfo() (totalKB uint64, availKB uint64, err error) {
-	f, err := os.Open("/proc/meminfo")
+// readMemInfo returns every /proc/meminfo field in kB. HugePages_* are page
+// counts rather than kB and are returned as-is.
+func readMemInfo(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 	defer func() {
 		err := f.Close()
@@ -88,25 +157,22 @@ func readMemInfo() (totalKB uint64, availKB uint64, err error) {
 		}
 	}()
 
+	info := make(map[string]uint64)
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := scanner.Text()
-		if strings.HasPrefix(line, "MemTotal:") {
-			totalKB = parseMemInfoKB(line)
-		}
-		if strings.HasPrefix(line, "MemAvailable:") {
-			availKB = parseMemInfoKB(line)
-		}
-		if totalKB > 0 && availKB > 0 {
-			break
+		key, _, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
 		}
+		info[key] = parseMemInfoKB(line)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 
-	return totalKB, availKB, nil
+	return info, nil
 }
 
 func parseMemInfoKB(line string) uint64 {
@@ -122,3 +188,35 @@ func parseMemInfoKB(line string) uint64 {
 
 	return value
 }
+
+// readZram sums the first three mm_stat columns (orig_data_size,
+// compr_data_size, mem_used_total, all in bytes) of every zram device.
+func readZram(root Root) ZramSnapshot {
+	var snapshot ZramSnapshot
+	paths, _ := filepath.Glob(root.sys("block", "zram*", "mm_stat"))
+	for _, path := range paths {
+		raw, err := readTrimmedFile(path)
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(raw)
+		if len(fields) < 3 {
+			continue
+		}
+
+		var values [3]uint64
+		for i := range values {
+			values[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+
+		snapshot.Devices++
+		snapshot.OrigGiB += float64(values[0]) / bytesPerGiB
+		snapshot.ComprGiB += float64(values[1]) / bytesPerGiB
+		snapshot.MemUsedGiB += float64(values[2]) / bytesPerGiB
+	}
+	if snapshot.ComprGiB > 0 {
+		snapshot.Ratio = snapshot.OrigGiB / snapshot.ComprGiB
+	}
+
+	return snapshot
+}
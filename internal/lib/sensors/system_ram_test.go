@@ -22,3 +22,51 @@ func TestParseMemInfoKB(t *testing.T) {
 		})
 	}
 }
+
+func TestReadMemorySnapshot(t *testing.T) {
+	root := Root{Proc: t.TempDir(), Sys: t.TempDir()}
+	writeFixture(t, root.Proc, "meminfo", ""+
+		"MemTotal:       33554432 kB\n"+
+		"MemFree:         2097152 kB\n"+
+		"MemAvailable:   16777216 kB\n"+
+		"Buffers:          524288 kB\n"+
+		"Cached:         12582912 kB\n"+
+		"SwapTotal:       8388608 kB\n"+
+		"SwapFree:        6291456 kB\n"+
+		"Dirty:              2048 kB\n"+
+		"Writeback:           512 kB\n"+
+		"Shmem:           1048576 kB\n"+
+		"HugePages_Total:     512\n"+
+		"HugePages_Free:      256\n"+
+		"Hugepagesize:       2048 kB\n")
+	writeFixture(t, root.Sys, "block/zram0/mm_stat", "4294967296 1073741824 1207959552 0 1207959552 1024 0 0 0\n")
+
+	ram, err := readMemorySnapshot(root)
+	if err != nil {
+		t.Fatalf("readMemorySnapshot error: %v", err)
+	}
+
+	want := SystemRAMSnapshot{
+		TotalGiB:         32,
+		UsedGiB:          16,
+		AvailGiB:         16,
+		UsedPct:          50,
+		FreeGiB:          2,
+		BuffersGiB:       0.5,
+		CachedGiB:        12,
+		ShmemGiB:         1,
+		DirtyMiB:         2,
+		WritebackMiB:     0.5,
+		SwapTotalGiB:     8,
+		SwapUsedGiB:      2,
+		SwapUsedPct:      25,
+		Zram:             ZramSnapshot{Devices: 1, OrigGiB: 4, ComprGiB: 1, MemUsedGiB: 1.125, Ratio: 4},
+		HugePagesTotal:   512,
+		HugePagesFree:    256,
+		HugePageSizeKiB:  2048,
+		HugePagesUsedGiB: 0.5,
+	}
+	if ram != want {
+		t.Fatalf("readMemorySnapshot got %+v, want %+v", ram, want)
+	}
+}
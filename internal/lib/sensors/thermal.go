@@ -0,0 +1,198 @@
+// Package sensors contains sensor metrics,
+// like cpu/gpu utilization, temperatures, power draw, etc.
+package sensors
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tripPointPattern matches trip_point_<n>_temp files.
+var tripPointPattern = regexp.MustCompile(`^trip_point_(\d+)_temp$`)
+
+// cpuThermalZoneTypes are zone types that track the CPU, in order of
+// preference: Intel package, common ARM SoC names, then the ACPI zone.
+var cpuThermalZoneTypes = []string{"x86_pkg_temp", "cpu-thermal", "cpu_thermal", "cpu0-thermal", "soc-thermal", "soc_thermal", "acpitz"}
+
+type ThermalSnapshot struct {
+	Zones   []ThermalZone
+	Cooling []CoolingDevice
+}
+
+type ThermalZone struct {
+	Name  string
+	Type  string
+	TempC float64
+	Trips []TripPoint
+}
+
+// TripPoint is a temperature at which the kernel acts, e.g. "passive"
+// (throttle), "active" (spin a fan up), "hot" or "critical" (shut down).
+type TripPoint struct {
+	Type  string
+	TempC float64
+}
+
+// CoolingDevice is a fan, CPU frequency limiter or similar the thermal
+// framework drives; CurState ranges from 0 (idle) to MaxState.
+type CoolingDevice struct {
+	Name     string
+	Type     string
+	CurState float64
+	MaxState float64
+}
+
+// CPUTempC returns the temperature of the most CPU-like zone, and false
+// when there is none. It covers ARM SBCs and laptops with no hwmon CPU chip.
+func (s ThermalSnapshot) CPUTempC() (float64, bool) {
+	for _, kind := range cpuThermalZoneTypes {
+		for _, zone := range s.Zones {
+			if zone.Type == kind {
+				return zone.TempC, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+type ThermalSampler struct {
+	lifecycle
+
+	mu       sync.RWMutex
+	snapshot ThermalSnapshot
+	root     Root
+}
+
+func NewThermalSampler(interval time.Duration, root Root) *ThermalSampler {
+	s := &ThermalSampler{root: root}
+	s.onStart(interval, s.run)
+
+	return s
+}
+
+func (s *ThermalSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot := readThermal(s.root)
+
+		s.mu.Lock()
+		s.snapshot = snapshot
+		s.mu.Unlock()
+	}
+}
+
+func (s *ThermalSampler) Snapshot() ThermalSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := ThermalSnapshot{
+		Zones:   make([]ThermalZone, 0, len(s.snapshot.Zones)),
+		Cooling: append([]CoolingDevice(nil), s.snapshot.Cooling...),
+	}
+	for _, zone := range s.snapshot.Zones {
+		zone.Trips = append([]TripPoint(nil), zone.Trips...)
+		snapshot.Zones = append(snapshot.Zones, zone)
+	}
+
+	return snapshot
+}
+
+func readThermal(root Root) ThermalSnapshot {
+	var snapshot ThermalSnapshot
+
+	zones, _ := filepath.Glob(root.sys("class", "thermal", "thermal_zone*"))
+	sort.Slice(zones, func(i, j int) bool {
+		return naturalLess(filepath.Base(zones[i]), filepath.Base(zones[j]))
+	})
+	for _, dir := range zones {
+		zone, err := readThermalZone(dir)
+		if err != nil {
+			continue
+		}
+		snapshot.Zones = append(snapshot.Zones, zone)
+	}
+
+	devices, _ := filepath.Glob(root.sys("class", "thermal", "cooling_device*"))
+	sort.Slice(devices, func(i, j int) bool {
+		return naturalLess(filepath.Base(devices[i]), filepath.Base(devices[j]))
+	})
+	for _, dir := range devices {
+		kind, err := readTrimmedFile(filepath.Join(dir, "type"))
+		if err != nil {
+			continue
+		}
+		snapshot.Cooling = append(snapshot.Cooling, CoolingDevice{
+			Name:     filepath.Base(dir),
+			Type:     kind,
+			CurState: readUintAsFloat(filepath.Join(dir, "cur_state")),
+			MaxState: readUintAsFloat(filepath.Join(dir, "max_state")),
+		})
+	}
+
+	return snapshot
+}
+
+// readThermalZone reads a zone's type, temperature and trip points. Zones
+// whose temp cannot be read (some firmware zones return EIO) are skipped.
+func readThermalZone(dir string) (ThermalZone, error) {
+	zone := ThermalZone{Name: filepath.Base(dir)}
+	zone.Type, _ = readTrimmedFile(filepath.Join(dir, "type"))
+
+	temp, err := readMillidegrees(filepath.Join(dir, "temp"))
+	if err != nil {
+		return ThermalZone{}, err
+	}
+	zone.TempC = temp
+
+	entries, _ := filepath.Glob(filepath.Join(dir, "trip_point_*_temp"))
+	var trips []int
+	for _, path := range entries {
+		m := tripPointPattern.FindStringSubmatch(filepath.Base(path))
+		if m == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(m[1])
+		trips = append(trips, index)
+	}
+	sort.Ints(trips)
+
+	for _, index := range trips {
+		prefix := filepath.Join(dir, "trip_point_"+strconv.Itoa(index))
+		temp, err := readMillidegrees(prefix + "_temp")
+		if err != nil {
+			continue
+		}
+		kind, _ := readTrimmedFile(prefix + "_type")
+		zone.Trips = append(zone.Trips, TripPoint{Type: kind, TempC: temp})
+	}
+
+	return zone, nil
+}
+
+// readMillidegrees reads a signed millidegree Celsius value as °C.
+func readMillidegrees(path string) (float64, error) {
+	raw, err := readTrimmedFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(value) / 1_000.0, nil
+}
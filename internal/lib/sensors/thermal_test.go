@@ -0,0 +1,66 @@
+package sensors
+
+import "testing"
+
+func TestReadThermal(t *testing.T) {
+	root := Root{Sys: t.TempDir()}
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone0/type", "cpu-thermal\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone0/temp", "52600\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone0/trip_point_0_type", "passive\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone0/trip_point_0_temp", "80000\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone0/trip_point_10_type", "critical\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone0/trip_point_10_temp", "110000\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone0/trip_point_2_type", "active\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone0/trip_point_2_temp", "60000\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone1/type", "gpu-thermal\n")
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone1/temp", "-5000\n")
+	// No temp file: the zone is skipped.
+	writeFixture(t, root.Sys, "class/thermal/thermal_zone2/type", "INT3400 Thermal\n")
+	writeFixture(t, root.Sys, "class/thermal/cooling_device0/type", "pwm-fan\n")
+	writeFixture(t, root.Sys, "class/thermal/cooling_device0/cur_state", "2\n")
+	writeFixture(t, root.Sys, "class/thermal/cooling_device0/max_state", "4\n")
+
+	snapshot := readThermal(root)
+	if len(snapshot.Zones) != 2 {
+		t.Fatalf("expected 2 zones, got %+v", snapshot.Zones)
+	}
+
+	cpu := snapshot.Zones[0]
+	if cpu.Name != "thermal_zone0" || cpu.Type != "cpu-thermal" || cpu.TempC != 52.6 {
+		t.Fatalf("cpu zone got %+v", cpu)
+	}
+	wantTrips := []TripPoint{{Type: "passive", TempC: 80}, {Type: "active", TempC: 60}, {Type: "critical", TempC: 110}}
+	if len(cpu.Trips) != len(wantTrips) {
+		t.Fatalf("trips got %+v, want %+v", cpu.Trips, wantTrips)
+	}
+	for i := range wantTrips {
+		if cpu.Trips[i] != wantTrips[i] {
+			t.Fatalf("trip %d got %+v, want %+v", i, cpu.Trips[i], wantTrips[i])
+		}
+	}
+	if snapshot.Zones[1].TempC != -5 {
+		t.Fatalf("negative temperature got %+v", snapshot.Zones[1])
+	}
+
+	want := CoolingDevice{Name: "cooling_device0", Type: "pwm-fan", CurState: 2, MaxState: 4}
+	if len(snapshot.Cooling) != 1 || snapshot.Cooling[0] != want {
+		t.Fatalf("cooling got %+v, want [%+v]", snapshot.Cooling, want)
+	}
+
+	if got, ok := snapshot.CPUTempC(); !ok || got != 52.6 {
+		t.Fatalf("CPUTempC got %v %v, want 52.6 true", got, ok)
+	}
+}
+
+func TestThermalCPUTempCPrefersPackageZone(t *testing.T) {
+	snapshot := ThermalSnapshot{Zones: []ThermalZone{
+		{Type: "acpitz", TempC: 40},
+		{Type: "x86_pkg_temp", TempC: 71},
+	}}
+	if got, ok := snapshot.CPUTempC(); !ok || got != 71 {
+		t.Fatalf("CPUTempC got %v %v, want 71 true", got, ok)
+	}
+	if got, ok := (ThermalSnapshot{Zones: []ThermalZone{{Type: "iwlwifi_1", TempC: 45}}}).CPUTempC(); ok || got != 0 {
+		t.Fatalf("CPUTempC without a CPU zone got %v %v, want 0 false", got, ok)
+	}
+}
@@ -2,8 +2,10 @@ package routes
 
 import (
 	"io/fs"
+	"strings"
 	"time"
 
+	"sensorpanel/internal/lib/sensors"
 	"sensorpanel/internal/server"
 	"sensorpanel/internal/services/metrics"
 	"sensorpanel/internal/services/settings"
@@ -79,8 +81,45 @@ func MetricsRoutes(s *server.Server) {
 		return
 	}
 
-	metricsHandler := metrics.New(s, metrics.WithSampleInterval(time.Second))
+	opts := []metrics.Option{metrics.WithSampleInterval(time.Second)}
+	if s.Env != nil {
+		opts = append(opts,
+			metrics.WithRoot(sensors.Root{Proc: s.Env.ProcfsRoot, Sys: s.Env.SysfsRoot}),
+			metrics.WithPrimaryGPU(s.Env.GPUPrimary),
+			metrics.WithGPUBackend(s.Env.GPUBackend),
+			metrics.WithAllDisks(s.Env.DiskIncludeAll),
+			metrics.WithAllNetInterfaces(s.Env.NetIncludeAll),
+			metrics.WithSmartctl(s.Env.StorageSmartctl),
+			metrics.WithProcessSampling(s.Env.ProcessSampling),
+			metrics.WithProcessTopN(s.Env.ProcessTopN),
+			metrics.WithFilesystemFilter(sensors.FSFilter{
+				IncludeTypes:  splitList(s.Env.FSIncludeTypes),
+				ExcludeTypes:  splitList(s.Env.FSExcludeTypes),
+				IncludeMounts: splitList(s.Env.FSIncludeMounts),
+				ExcludeMounts: splitList(s.Env.FSExcludeMounts),
+			}),
+			metrics.WithCgroupPaths(splitList(s.Env.CgroupPaths)),
+		)
+	}
+
+	metricsHandler := metrics.New(s, opts...)
 
 	s.Get("/metrics", metricsHandler.GetMetrics)
 	s.Get("/metrics/ws", metricsHandler.NewMetricsWS())
+	s.Get("/api/processes", metricsHandler.GetProcesses)
+	s.Get("/api/filesystems", metricsHandler.GetFilesystems)
+	s.Get("/api/cgroups", metricsHandler.GetCgroups)
+	s.Get("/api/sensors", metricsHandler.GetSensors)
+}
+
+// splitList splits a comma-separated env value, dropping empty entries.
+func splitList(raw string) []string {
+	var list []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
 }
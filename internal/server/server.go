@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -25,6 +26,10 @@ type Server struct {
 	WSHub    *wshub.Hub
 	port     int
 	fiberCfg *fiber.Config
+
+	ctx        context.Context
+	cancel     context.CancelFunc
+	onShutdown []func()
 }
 
 type ServerOption func(*Server) error
@@ -39,6 +44,12 @@ func New(opts ...ServerOption) (*Server, error) {
 		}
 	}
 
+	base := s.ctx
+	if base == nil {
+		base = context.Background()
+	}
+	s.ctx, s.cancel = context.WithCancel(base)
+
 	if s.fiberCfg != nil {
 		s.App = fiber.New(*s.fiberCfg)
 	} else {
@@ -69,14 +80,45 @@ func (s *Server) Listen(portOverride ...int) error {
 	return s.App.Listen(":" + strconv.Itoa(port))
 }
 
+// Shutdown cancels Context, closes the WS hub and the HTTP server, then runs
+// the OnShutdown hooks in reverse registration order.
 func (s *Server) Shutdown() error {
 	if s == nil || s.App == nil {
 		return nil
 	}
 
+	if s.cancel != nil {
+		s.cancel()
+	}
+
 	s.CloseWSHubConnections()
 
-	return s.App.Shutdown()
+	err := s.App.Shutdown()
+	for i := len(s.onShutdown) - 1; i >= 0; i-- {
+		s.onShutdown[i]()
+	}
+
+	return err
+}
+
+// Context is cancelled on Shutdown, or earlier when the context passed to
+// WithContext is. Background work started by services should stop with it.
+func (s *Server) Context() context.Context {
+	if s == nil || s.ctx == nil {
+		return context.Background()
+	}
+
+	return s.ctx
+}
+
+// OnShutdown registers fn to run at the end of Shutdown, e.g. to wait for
+// a service's background goroutines to return.
+func (s *Server) OnShutdown(fn func()) {
+	if s == nil || fn == nil {
+		return
+	}
+
+	s.onShutdown = append(s.onShutdown, fn)
 }
 
 func (s *Server) AddSettingsWSConn(conn *websocket.Conn) error {
@@ -158,6 +200,18 @@ func WithFiberConfig(cfg fiber.Config) ServerOption {
 	}
 }
 
+// WithContext sets the parent of Context, typically main's signal context.
+func WithContext(ctx context.Context) ServerOption {
+	return func(s *Server) error {
+		if ctx == nil {
+			return fmt.Errorf("context is required")
+		}
+
+		s.ctx = ctx
+		return nil
+	}
+}
+
 func WithWSHub(hub *wshub.Hub) ServerOption {
 	return func(s *Server) error {
 		if hub == nil {
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"sort"
+
+	"sensorpanel/internal/lib/sensors"
+)
+
+const (
+	GPUBackendAuto   = "auto"
+	GPUBackendAMDGPU = "amdgpu"
+	GPUBackendNvidia = "nvidia"
+	GPUBackendIntel  = "intel"
+)
+
+func (m *Service) gpuBackendEnabled(backend string) bool {
+	return m.gpuBackend == "" || m.gpuBackend == GPUBackendAuto || m.gpuBackend == backend
+}
+
+// gpuReaders are the GPU samplers; backends that are disabled or absent
+// are nil.
+type gpuReaders struct {
+	hwmon  reader[sensors.LmSensorsSnapshot]
+	busy   reader[sensors.GPUBusySnapshot]
+	vram   reader[sensors.GPUVRAMSnapshot]
+	nvidia reader[sensors.NvidiaSMISnapshot]
+	intel  reader[sensors.IntelGPUSnapshot]
+	amdgpu reader[sensors.AMDGPUMetricsSnapshot]
+	clock  reader[sensors.GPUClockSnapshot]
+}
+
+func (r gpuReaders) samplers() []sensors.Sampler {
+	return []sensors.Sampler{r.hwmon, r.busy, r.vram, r.nvidia, r.intel, r.amdgpu, r.clock}
+}
+
+// gpus merges every GPU sampler's readings, sorted by PCI address.
+func (r gpuReaders) gpus() []GPU {
+	var gpus gpuSet
+	if r.hwmon != nil {
+		gpus.addHwmon(r.hwmon.Snapshot().GPUs)
+	}
+	if r.busy != nil {
+		gpus.addBusy(r.busy.Snapshot())
+	}
+	if r.vram != nil {
+		gpus.addVRAM(r.vram.Snapshot())
+	}
+	if r.nvidia != nil {
+		gpus.addNvidia(r.nvidia.Snapshot())
+	}
+	if r.intel != nil {
+		gpus.addIntel(r.intel.Snapshot())
+	}
+	if r.amdgpu != nil {
+		gpus.addAMDGPUMetrics(r.amdgpu.Snapshot())
+	}
+	if r.clock != nil {
+		gpus.addClocks(r.clock.Snapshot())
+	}
+
+	return gpus.list()
+}
+
+// gpuSet merges the per-card readings of the GPU samplers by PCI address.
+type gpuSet struct {
+	byAddr map[string]*GPU
+	addrs  []string
+}
+
+func (s *gpuSet) gpu(addr string) *GPU {
+	if g, ok := s.byAddr[addr]; ok {
+		return g
+	}
+
+	if s.byAddr == nil {
+		s.byAddr = make(map[string]*GPU)
+	}
+	s.byAddr[addr] = &GPU{PCIAddr: addr, ThrottleReasons: []string{}, Engines: []GPUEngine{}}
+	s.addrs = append(s.addrs, addr)
+	return s.byAddr[addr]
+}
+
+func (s *gpuSet) addHwmon(hwmon []sensors.GPUSensors) {
+	for _, h := range hwmon {
+		g := s.gpu(h.PCIAddr)
+		g.EdgeC = h.EdgeC
+		g.HotspotC = h.HotspotC
+		g.VramC = h.VramC
+		g.PowerW = h.PowerW
+		g.PowerCapW = h.PowerCapW
+		g.PowerCapDefaultW = h.PowerCapDefaultW
+		g.FanRPM = h.FanRPM
+		g.FanPWMPct = h.FanPWMPct
+		g.readings |= h.Readings
+	}
+}
+
+func (s *gpuSet) addBusy(busy sensors.GPUBusySnapshot) {
+	for _, b := range busy.GPUs {
+		g := s.gpu(b.PCIAddr)
+		g.Card = b.Card
+		g.UtilPct = b.UtilPct
+		g.readings |= sensors.GPUUtil
+	}
+}
+
+func (s *gpuSet) addVRAM(vram sensors.GPUVRAMSnapshot) {
+	for _, v := range vram.GPUs {
+		g := s.gpu(v.PCIAddr)
+		g.Card = v.Card
+		g.VramUsedGB = v.UsedGB
+		g.VramTotalGB = v.TotalGB
+		g.VramUsedPct = v.UsedPct
+	}
+}
+
+func (s *gpuSet) addNvidia(nvidia sensors.NvidiaSMISnapshot) {
+	for _, n := range nvidia.GPUs {
+		g := s.gpu(n.PCIAddr)
+		g.Name = n.Name
+		g.EdgeC = n.TempC
+		g.VramC = n.VramTempC
+		g.VramUsedGB = n.VramUsedGB
+		g.VramTotalGB = n.VramTotalGB
+		g.VramUsedPct = n.VramUsedPct
+		g.PowerW = n.PowerW
+		g.UtilPct = n.UtilPct
+		g.GraphicsClockMHz = n.GraphicsClockMHz
+		g.MemClockMHz = n.MemClockMHz
+		g.readings |= n.Readings
+	}
+}
+
+func (s *gpuSet) addIntel(intel sensors.IntelGPUSnapshot) {
+	for _, i := range intel.GPUs {
+		g := s.gpu(i.PCIAddr)
+		g.Card = i.Card
+		g.set(&g.UtilPct, i.UtilPct, i.Readings, sensors.GPUUtil)
+		g.set(&g.GraphicsClockMHz, i.ActFreqMHz, i.Readings, sensors.GPUGraphicsClock)
+		g.Engines = make([]GPUEngine, 0, len(i.Engines))
+		for _, engine := range i.Engines {
+			g.Engines = append(g.Engines, GPUEngine{Name: engine.Name, BusyPct: engine.BusyPct})
+		}
+	}
+}
+
+// addAMDGPUMetrics adds clocks and throttling from gpu_metrics.
+// Temperatures, power, fan speed and utilization only fill gaps left by
+// hwmon and gpu_busy_percent, which stay the primary source.
+func (s *gpuSet) addAMDGPUMetrics(metrics sensors.AMDGPUMetricsSnapshot) {
+	for _, a := range metrics.GPUs {
+		g := s.gpu(a.PCIAddr)
+		g.Card = a.Card
+		g.fill(&g.EdgeC, a.EdgeC, a.Readings, sensors.GPUEdgeTemp)
+		g.fill(&g.HotspotC, a.HotspotC, a.Readings, sensors.GPUHotspotTemp)
+		g.fill(&g.VramC, a.MemC, a.Readings, sensors.GPUMemTemp)
+		g.fill(&g.PowerW, a.SocketPowerW, a.Readings, sensors.GPUPower)
+		g.fill(&g.UtilPct, a.GfxActivityPct, a.Readings, sensors.GPUUtil)
+		g.set(&g.GraphicsClockMHz, a.GfxClockMHz, a.Readings, sensors.GPUGraphicsClock)
+		g.SocClockMHz = a.SocClockMHz
+		g.set(&g.MemClockMHz, a.MemClockMHz, a.Readings, sensors.GPUMemClock)
+		g.fill(&g.FanRPM, a.FanRPM, a.Readings, sensors.GPUFan)
+		g.Throttling = a.Throttling()
+		g.ThrottleReasons = append([]string{}, a.Throttlers...)
+	}
+}
+
+// addClocks fills clocks from the active pp_dpm level on cards where
+// gpu_metrics did not report them.
+func (s *gpuSet) addClocks(clocks sensors.GPUClockSnapshot) {
+	for _, c := range clocks.GPUs {
+		g := s.gpu(c.PCIAddr)
+		g.Card = c.Card
+		g.fill(&g.GraphicsClockMHz, c.ShaderClockMHz, c.Readings, sensors.GPUGraphicsClock)
+		g.fill(&g.MemClockMHz, c.MemClockMHz, c.Readings, sensors.GPUMemClock)
+	}
+}
+
+// list returns the merged GPUs ordered by PCI address.
+func (s *gpuSet) list() []GPU {
+	addrs := append([]string(nil), s.addrs...)
+	sort.Strings(addrs)
+
+	list := make([]GPU, 0, len(addrs))
+	for _, addr := range addrs {
+		list = append(list, *s.byAddr[addr])
+	}
+
+	return list
+}
+
+// set stores v in *dst when the backend reported the reading.
+func (g *GPU) set(dst *float64, v float64, reported sensors.GPUReadings, reading sensors.GPUReadings) {
+	if reported.Has(reading) {
+		*dst = v
+		g.readings |= reading
+	}
+}
+
+// fill is set for readings no earlier backend reported.
+func (g *GPU) fill(dst *float64, v float64, reported sensors.GPUReadings, reading sensors.GPUReadings) {
+	if !g.readings.Has(reading) {
+		g.set(dst, v, reported, reading)
+	}
+}
+
+func primaryGPU(list []GPU, id string) GPU {
+	for _, g := range list {
+		if id != "" && (g.PCIAddr == id || g.Card == id) {
+			return g
+		}
+	}
+	if len(list) > 0 {
+		return list[0]
+	}
+
+	return GPU{ThrottleReasons: []string{}, Engines: []GPUEngine{}}
+}
@@ -2,33 +2,101 @@
 package metrics
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gofiber/contrib/v3/websocket"
 	"github.com/gofiber/fiber/v3"
 )
 
+// Opt-in /metrics/ws topics, requested with e.g. ?topics=processes,cgroups.
+const (
+	TopicProcesses = "processes"
+	TopicCgroups   = "cgroups"
+	TopicSensors   = "sensors"
+)
+
 func (m *Service) GetMetrics(c fiber.Ctx) error {
 	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
 	return c.JSON(m.buildSnapshot())
 }
 
+func (m *Service) GetProcesses(c fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.JSON(m.buildProcesses())
+}
+
+func (m *Service) GetFilesystems(c fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.JSON(m.buildFilesystems())
+}
+
+func (m *Service) GetCgroups(c fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.JSON(m.buildCgroups())
+}
+
+// GetSensors serves the registry. ?prefix=gpu.0. keeps only matching IDs.
+func (m *Service) GetSensors(c fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.JSON(m.buildSensors(c.Query("prefix")))
+}
+
 func (m *Service) NewMetricsWS() fiber.Handler {
 	return websocket.New(func(conn *websocket.Conn) {
 		ticker := time.NewTicker(m.sampleInterval)
 		defer ticker.Stop()
 		defer conn.Close()
 
+		topics := parseTopics(conn.Query("topics"))
+
 		// Initial snapshot
-		if err := conn.WriteJSON(m.buildSnapshot()); err != nil {
+		if err := conn.WriteJSON(m.buildFrame(topics)); err != nil {
 			return
 		}
 
-		// Periodic updates
-		for range ticker.C {
-			if err := conn.WriteJSON(m.buildSnapshot()); err != nil {
+		// Periodic updates until the client goes away or the server shuts
+		// down.
+		done := m.Context().Done()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if err := conn.WriteJSON(m.buildFrame(topics)); err != nil {
 				return
 			}
 		}
 	})
 }
+
+// buildFrame is a Snapshot plus the opt-in topics a client asked for, each
+// under its topic name.
+func (m *Service) buildFrame(topics map[string]bool) Snapshot {
+	frame := m.buildSnapshot()
+	if topics[TopicProcesses] && m.processSample {
+		frame[TopicProcesses] = m.buildProcesses()
+	}
+	if topics[TopicCgroups] {
+		frame[TopicCgroups] = m.buildCgroups()
+	}
+	if topics[TopicSensors] {
+		frame[TopicSensors] = m.buildSensors("")
+	}
+
+	return frame
+}
+
+// parseTopics splits a comma-separated ?topics= value.
+func parseTopics(raw string) map[string]bool {
+	topics := make(map[string]bool)
+	for _, topic := range strings.Split(raw, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics[topic] = true
+		}
+	}
+
+	return topics
+}
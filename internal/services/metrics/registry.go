@@ -0,0 +1,234 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"sensorpanel/internal/lib/sensors"
+)
+
+// Units reported in Metric.Unit.
+const (
+	UnitCelsius     = "C"
+	UnitWatts       = "W"
+	UnitVolts       = "V"
+	UnitPercent     = "%"
+	UnitMHz         = "MHz"
+	UnitRPM         = "RPM"
+	UnitGiB         = "GiB"
+	UnitMBPerSec    = "MB/s"
+	UnitBytesPerSec = "B/s"
+	UnitIOPS        = "IOPS"
+	UnitMinutes     = "min"
+)
+
+// Metric is one reading under a stable, dot-separated ID such as
+// cpu.temp_c or gpu.0.edge_c. Meta carries identifying details that are not
+// part of the ID, e.g. a GPU's PCI address or a fan's chip.
+type Metric struct {
+	ID     string            `json:"id"`
+	Label  string            `json:"label"`
+	Unit   string            `json:"unit"`
+	Value  float64           `json:"value"`
+	Source string            `json:"source"`
+	Meta   map[string]string `json:"meta,omitempty"`
+}
+
+// Provider reports the metrics of one sensor source. Metrics returns only
+// the readings this machine has, so an absent sensor is simply missing
+// from /api/sensors rather than reported as 0.
+type Provider interface {
+	Name() string
+	Metrics() []Metric
+}
+
+// Source is one registered sensor backend. It runs the samplers behind it,
+// reports their readings on /api/sensors and owns its sections of the
+// /metrics Snapshot, so registering a Source is all a new sensor needs.
+type Source interface {
+	Provider
+	sensors.Sampler
+	// Sections returns the top-level /metrics keys this source owns, e.g.
+	// "fans" and "voltages", with their JSON-encodable values.
+	Sections() map[string]any
+}
+
+type source struct {
+	name     string
+	samplers []sensors.Sampler
+	metrics  func() []Metric
+	sections func() map[string]any
+}
+
+// NewSource builds a Source from the samplers it runs and the functions
+// reporting their readings. Nil samplers are skipped, and metrics or
+// sections may be nil for a source with nothing to report there.
+func NewSource(name string, samplers []sensors.Sampler, metrics func() []Metric, sections func() map[string]any) Source {
+	src := source{name: name, metrics: metrics, sections: sections}
+	for _, sampler := range samplers {
+		if sampler != nil {
+			src.samplers = append(src.samplers, sampler)
+		}
+	}
+
+	return src
+}
+
+func (s source) Name() string { return s.name }
+
+func (s source) Metrics() []Metric {
+	if s.metrics == nil {
+		return nil
+	}
+
+	return s.metrics()
+}
+
+func (s source) Sections() map[string]any {
+	if s.sections == nil {
+		return nil
+	}
+
+	return s.sections()
+}
+
+// Start starts every sampler of the source. A sampler shared by two
+// sources, like lm-sensors for hwmon and gpu, is only started once since
+// Start on a running sampler does nothing.
+func (s source) Start(ctx context.Context) {
+	for _, sampler := range s.samplers {
+		sampler.Start(ctx)
+	}
+}
+
+func (s source) Stop() {
+	for _, sampler := range s.samplers {
+		sampler.Stop()
+	}
+}
+
+// Registry holds the registered sources. Metrics, Snapshot sections and
+// sampler lifecycles all come from it.
+type Registry struct {
+	mu      sync.RWMutex
+	sources []Source
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds src. Source names must be unique.
+func (r *Registry) Register(src Source) error {
+	if src == nil {
+		return fmt.Errorf("source is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, registered := range r.sources {
+		if registered.Name() == src.Name() {
+			return fmt.Errorf("source %q is already registered", src.Name())
+		}
+	}
+	r.sources = append(r.sources, src)
+
+	return nil
+}
+
+func (r *Registry) list() []Source {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Source(nil), r.sources...)
+}
+
+// Providers returns the registered source names in registration order.
+func (r *Registry) Providers() []string {
+	sources := r.list()
+	names := make([]string, 0, len(sources))
+	for _, src := range sources {
+		names = append(names, src.Name())
+	}
+
+	return names
+}
+
+// Collect gathers the metrics of every source, sorted by ID. When two
+// sources report the same ID the first registered wins. A non-empty
+// prefix keeps only IDs starting with it, e.g. "gpu.0.".
+func (r *Registry) Collect(prefix string) []Metric {
+	seen := make(map[string]bool)
+	collected := []Metric{}
+	for _, src := range r.list() {
+		for _, metric := range src.Metrics() {
+			if seen[metric.ID] || !strings.HasPrefix(metric.ID, prefix) {
+				continue
+			}
+			seen[metric.ID] = true
+			metric.Source = src.Name()
+			collected = append(collected, metric)
+		}
+	}
+
+	sort.Slice(collected, func(i, j int) bool {
+		return collected[i].ID < collected[j].ID
+	})
+
+	return collected
+}
+
+// Snapshot gathers the sections of every source. When two sources report
+// the same section the first registered wins, as in Collect.
+func (r *Registry) Snapshot() Snapshot {
+	snapshot := make(Snapshot)
+	for _, src := range r.list() {
+		for key, section := range src.Sections() {
+			if _, ok := snapshot[key]; !ok {
+				snapshot[key] = section
+			}
+		}
+	}
+
+	return snapshot
+}
+
+// Start starts the samplers of every source until ctx is cancelled or Stop
+// is called.
+func (r *Registry) Start(ctx context.Context) {
+	for _, src := range r.list() {
+		src.Start(ctx)
+	}
+}
+
+// Stop stops the samplers of every source and waits for them to return.
+func (r *Registry) Stop() {
+	for _, src := range r.list() {
+		src.Stop()
+	}
+}
+
+// metricID joins parts with dots after lowercasing them and replacing
+// anything outside [a-z0-9_-] with an underscore, so names such as
+// "0000:03:00.0" or "Package id 0" cannot break the ID's structure.
+func metricID(parts ...string) string {
+	clean := make([]string, 0, len(parts))
+	for _, part := range parts {
+		clean = append(clean, strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+				return r
+			case r >= 'A' && r <= 'Z':
+				return r + ('a' - 'A')
+			default:
+				return '_'
+			}
+		}, part))
+	}
+
+	return strings.Join(clean, ".")
+}
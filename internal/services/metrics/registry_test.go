@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistryCollect(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(NewSource("hwmon", nil, func() []Metric {
+		return []Metric{
+			{ID: "fan.nct6798.fan2.rpm", Unit: UnitRPM, Value: 1120},
+			{ID: "cpu.temp_c", Unit: UnitCelsius, Value: 72},
+		}
+	}, nil)); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if err := r.Register(NewSource("thermal", nil, func() []Metric {
+		return []Metric{
+			{ID: "cpu.temp_c", Unit: UnitCelsius, Value: 55},
+			{ID: "thermal.thermal_zone0.temp_c", Unit: UnitCelsius, Value: 55},
+		}
+	}, nil)); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+
+	got := r.Collect("")
+	want := []Metric{
+		{ID: "cpu.temp_c", Unit: UnitCelsius, Value: 72, Source: "hwmon"},
+		{ID: "fan.nct6798.fan2.rpm", Unit: UnitRPM, Value: 1120, Source: "hwmon"},
+		{ID: "thermal.thermal_zone0.temp_c", Unit: UnitCelsius, Value: 55, Source: "thermal"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Collect got %+v, want %+v", got, want)
+	}
+
+	if got := r.Collect("thermal."); len(got) != 1 || got[0].ID != "thermal.thermal_zone0.temp_c" {
+		t.Fatalf("Collect with prefix got %+v", got)
+	}
+	if got := r.Collect("gpu."); got == nil || len(got) != 0 {
+		t.Fatalf("Collect with no match should be an empty list, got %#v", got)
+	}
+	if got := r.Providers(); !reflect.DeepEqual(got, []string{"hwmon", "thermal"}) {
+		t.Fatalf("Providers got %v", got)
+	}
+}
+
+func TestRegistryRejectsDuplicateSource(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(NewSource("cpu", nil, nil, nil)); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if err := r.Register(NewSource("cpu", nil, nil, nil)); err == nil {
+		t.Fatal("expected error for duplicate source name")
+	}
+	if err := r.Register(nil); err == nil {
+		t.Fatal("expected error for nil source")
+	}
+}
+
+func TestRegistrySnapshotFirstSourceWins(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"hwmon", "thermal"} {
+		if err := r.Register(NewSource(name, nil, nil, func() map[string]any {
+			return map[string]any{"fans": []Fan{{Chip: name}}, name: true}
+		})); err != nil {
+			t.Fatalf("Register error: %v", err)
+		}
+	}
+
+	s := r.Snapshot()
+	if fans, ok := s["fans"].([]Fan); !ok || len(fans) != 1 || fans[0].Chip != "hwmon" {
+		t.Fatalf("fans section got %#v", s["fans"])
+	}
+	if s["hwmon"] != true || s["thermal"] != true || len(s) != 3 {
+		t.Fatalf("Snapshot got %#v", s)
+	}
+}
+
+func TestMetricID(t *testing.T) {
+	tests := map[string][]string{
+		"gpu.0.edge_c":                       {"gpu", "0", "edge_c"},
+		"fan.amdgpu-0000_03_00_0.fan1.rpm":   {"fan", "amdgpu-0000:03:00.0", "fan1", "rpm"},
+		"cpu.power.intel-rapl_0.power_w":     {"cpu", "power", "intel-rapl:0", "power_w"},
+		"thermal.acpitz_package_id_0.temp_c": {"thermal", "acpitz Package id 0", "temp_c"},
+	}
+
+	for want, parts := range tests {
+		if got := metricID(parts...); got != want {
+			t.Fatalf("metricID(%q) got %q, want %q", parts, got, want)
+		}
+	}
+}
@@ -1,75 +1,366 @@
 package metrics
 
 import (
+	"context"
+	"log"
 	"time"
 
 	"sensorpanel/internal/lib/sensors"
 	"sensorpanel/internal/server"
 )
 
-type cpuBusyReader interface {
-	Snapshot() sensors.CPUBusySnapshot
+// reader is a sampler together with its latest readings. Every sensors
+// sampler is a reader of its own snapshot type.
+type reader[T any] interface {
+	sensors.Sampler
+	Snapshot() T
 }
 
-type cpuPowerReader interface {
-	Snapshot() sensors.CPUPowerSnapshot
+// fallibleReader is a reader whose Snapshot can fail, like the RAM sampler.
+type fallibleReader[T any] interface {
+	sensors.Sampler
+	Snapshot() (T, error)
 }
 
-type ramReader interface {
-	Snapshot() (sensors.SystemRAMSnapshot, error)
+type Service struct {
+	*server.Server
+	sampleInterval time.Duration
+	root           sensors.Root
+	primaryGPU     string
+	gpuBackend     string
+	allDisks       bool
+	allNetIfaces   bool
+	smartctl       bool
+	processTopN    int
+	processSample  bool
+	fsFilter       sensors.FSFilter
+	cgroupPaths    []string
+	sources        []Source
+	registry       *Registry
+
+	// processes and cgroups are served on their own endpoints rather than
+	// from the Snapshot, so the service keeps their readers. filesystems is
+	// also in the Snapshot but kept so /api/filesystems can read it alone.
+	// Any of them is nil when not sampled.
+	processes   reader[sensors.ProcessSnapshot]
+	cgroups     reader[sensors.CgroupSnapshot]
+	filesystems reader[sensors.FilesystemSnapshot]
 }
 
-type lmSensorsReader interface {
-	Snapshot() sensors.LmSensorsSnapshot
+type Option func(*Service)
+
+// Snapshot is the /metrics document: the sections of every registered
+// source by top-level key, e.g. "cpu" holds a CPU and "gpus" a []GPU.
+type Snapshot map[string]any
+
+type CPU struct {
+	TempC        float64 `json:"temp_c"`
+	PackageTempC float64 `json:"package_temp_c"`
+	UtilPct      float64 `json:"util_pct"`
+	PowerW       float64 `json:"power_w"`
+	CPUTimes
+	Cores        []CPUCore        `json:"cores"`
+	Freq         CPUFreq          `json:"freq"`
+	PowerDomains []CPUPowerDomain `json:"power_domains"`
+	Throttling   bool             `json:"throttling"`
+	Throttle     CPUThrottle      `json:"throttle"`
 }
 
-type gpuBusyReader interface {
-	Snapshot() sensors.GPUBusySnapshot
+// Pressures is the pressure section, one Pressure per resource.
+type Pressures struct {
+	CPU    Pressure `json:"cpu"`
+	Memory Pressure `json:"memory"`
+	IO     Pressure `json:"io"`
 }
 
-type gpuVRAMReader interface {
-	Snapshot() sensors.GPUVRAMSnapshot
+type Thermal struct {
+	Zones   []ThermalZone   `json:"zones"`
+	Cooling []CoolingDevice `json:"cooling"`
 }
 
-type Service struct {
-	*server.Server
-	sampleInterval time.Duration
+// Filesystem is one mount. UsedPct is relative to used+free, as df reports
+// it, so root-reserved blocks do not hide a full disk.
+type Filesystem struct {
+	MountPoint    string  `json:"mount_point"`
+	Device        string  `json:"device"`
+	FSType        string  `json:"fs_type"`
+	TotalGiB      float64 `json:"total_gib"`
+	UsedGiB       float64 `json:"used_gib"`
+	FreeGiB       float64 `json:"free_gib"`
+	UsedPct       float64 `json:"used_pct"`
+	InodesTotal   uint64  `json:"inodes_total"`
+	InodesUsed    uint64  `json:"inodes_used"`
+	InodesUsedPct float64 `json:"inodes_used_pct"`
+}
 
-	cpuSampler     cpuBusyReader
-	cpuPower       cpuPowerReader
-	ramSampler     ramReader
-	sensorsSampler lmSensorsReader
-	gpuBusySampler gpuBusyReader
-	gpuVRAMSampler gpuVRAMReader
+type ThermalZone struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	TempC float64     `json:"temp_c"`
+	Trips []TripPoint `json:"trips"`
 }
 
-type Option func(*Service)
+type TripPoint struct {
+	Type  string  `json:"type"`
+	TempC float64 `json:"temp_c"`
+}
+
+type CoolingDevice struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	CurState float64 `json:"cur_state"`
+	MaxState float64 `json:"max_state"`
+}
+
+type Battery struct {
+	ACOnline  bool          `json:"ac_online"`
+	Batteries []BatteryInfo `json:"batteries"`
+}
+
+// BatteryInfo is one battery. PowerW is positive while charging and
+// negative while discharging.
+type BatteryInfo struct {
+	Name           string  `json:"name"`
+	Status         string  `json:"status"`
+	CapacityPct    float64 `json:"capacity_pct"`
+	PowerW         float64 `json:"power_w"`
+	EnergyWh       float64 `json:"energy_wh"`
+	EnergyFullWh   float64 `json:"energy_full_wh"`
+	EnergyDesignWh float64 `json:"energy_design_wh"`
+	HealthPct      float64 `json:"health_pct"`
+	TimeToEmptyMin float64 `json:"time_to_empty_min"`
+	TimeToFullMin  float64 `json:"time_to_full_min"`
+}
 
-type Snapshot struct {
-	CPU struct {
-		TempC        float64 `json:"temp_c"`
-		PackageTempC float64 `json:"package_temp_c"`
-		UtilPct      float64 `json:"util_pct"`
-		PowerW       float64 `json:"power_w"`
-	} `json:"cpu"`
-
-	RAM struct {
-		TotalGB float64 `json:"total_gb"`
-		UsedGB  float64 `json:"used_gb"`
-		AvailGB float64 `json:"avail_gb"`
-		UsedPct float64 `json:"used_pct"`
-	} `json:"ram"`
-
-	GPU struct {
-		EdgeC       float64 `json:"edge_c"`
-		HotspotC    float64 `json:"hotspot_c"`
-		VramC       float64 `json:"vram_c"`
-		VramUsedGB  float64 `json:"vram_used_gb"`
-		VramTotalGB float64 `json:"vram_total_gb"`
-		VramUsedPct float64 `json:"vram_used_pct"`
-		PowerW      float64 `json:"power_w"`
-		UtilPct     float64 `json:"util_pct"`
-	} `json:"gpu"`
+type Drive struct {
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Model      string  `json:"model"`
+	TempC      float64 `json:"temp_c"`
+	TempWarnC  float64 `json:"temp_warn_c"`
+	TempCritC  float64 `json:"temp_crit_c"`
+	Throttling bool    `json:"throttling"`
+
+	SMART                      bool    `json:"smart"`
+	HealthPassed               bool    `json:"health_passed"`
+	PercentageUsed             float64 `json:"percentage_used"`
+	AvailableSparePct          float64 `json:"available_spare_pct"`
+	AvailableSpareThresholdPct float64 `json:"available_spare_threshold_pct"`
+	MediaErrors                uint64  `json:"media_errors"`
+	CriticalWarning            uint64  `json:"critical_warning"`
+	WarningTempMinutes         uint64  `json:"warning_temp_minutes"`
+}
+
+type NetIO struct {
+	Name            string  `json:"name"`
+	State           string  `json:"state"`
+	SpeedMbps       float64 `json:"speed_mbps"`
+	RxBytesPerSec   float64 `json:"rx_bytes_s"`
+	TxBytesPerSec   float64 `json:"tx_bytes_s"`
+	RxPacketsPerSec float64 `json:"rx_packets_s"`
+	TxPacketsPerSec float64 `json:"tx_packets_s"`
+	RxErrorsPerSec  float64 `json:"rx_errors_s"`
+	TxErrorsPerSec  float64 `json:"tx_errors_s"`
+}
+
+// Processes is served on its own endpoint rather than in Snapshot because
+// walking every /proc/<pid> is heavier than the core sensors.
+type Processes struct {
+	ByCPU []Process `json:"by_cpu"`
+	ByRSS []Process `json:"by_rss"`
+	ByGPU []Process `json:"by_gpu"`
+}
+
+// Cgroups is served on its own endpoint, like Processes, and is opt-in on
+// the WS stream.
+type Cgroups struct {
+	Groups []Cgroup `json:"groups"`
+}
+
+// Cgroup is one cgroup v2 group. CPUPct is relative to one core; MemoryMaxMiB
+// and MemoryPct are 0 when the group has no memory limit.
+type Cgroup struct {
+	Path           string  `json:"path"`
+	CPUPct         float64 `json:"cpu_pct"`
+	MemoryMiB      float64 `json:"memory_mib"`
+	MemoryMaxMiB   float64 `json:"memory_max_mib"`
+	MemoryPct      float64 `json:"memory_pct"`
+	ReadBytesPerS  float64 `json:"read_bytes_s"`
+	WriteBytesPerS float64 `json:"write_bytes_s"`
+	ReadIOPS       float64 `json:"read_iops"`
+	WriteIOPS      float64 `json:"write_iops"`
+}
+
+type Process struct {
+	PID     int     `json:"pid"`
+	Name    string  `json:"name"`
+	CPUPct  float64 `json:"cpu_pct"`
+	RSSMiB  float64 `json:"rss_mib"`
+	GPUPct  float64 `json:"gpu_pct"`
+	VRAMMiB float64 `json:"vram_mib"`
+}
+
+// Pressure is one /proc/pressure resource. Some is the share of time at
+// least one task stalled on it, Full the share all non-idle tasks did.
+type Pressure struct {
+	Some PressureLine `json:"some"`
+	Full PressureLine `json:"full"`
+}
+
+type PressureLine struct {
+	Avg10    float64 `json:"avg10"`
+	Avg60    float64 `json:"avg60"`
+	Avg300   float64 `json:"avg300"`
+	StallPct float64 `json:"stall_pct"`
+}
+
+// RAM sizes are binary units: GiB and MiB.
+type RAM struct {
+	TotalGiB float64 `json:"total_gib"`
+	UsedGiB  float64 `json:"used_gib"`
+	AvailGiB float64 `json:"avail_gib"`
+	UsedPct  float64 `json:"used_pct"`
+
+	FreeGiB      float64 `json:"free_gib"`
+	BuffersGiB   float64 `json:"buffers_gib"`
+	CachedGiB    float64 `json:"cached_gib"`
+	ShmemGiB     float64 `json:"shmem_gib"`
+	DirtyMiB     float64 `json:"dirty_mib"`
+	WritebackMiB float64 `json:"writeback_mib"`
+
+	Swap struct {
+		TotalGiB float64 `json:"total_gib"`
+		UsedGiB  float64 `json:"used_gib"`
+		UsedPct  float64 `json:"used_pct"`
+	} `json:"swap"`
+
+	Zram struct {
+		Devices    int     `json:"devices"`
+		OrigGiB    float64 `json:"orig_gib"`
+		ComprGiB   float64 `json:"compr_gib"`
+		MemUsedGiB float64 `json:"mem_used_gib"`
+		Ratio      float64 `json:"ratio"`
+	} `json:"zram"`
+
+	HugePages struct {
+		Total   uint64  `json:"total"`
+		Free    uint64  `json:"free"`
+		SizeKiB uint64  `json:"size_kib"`
+		UsedGiB float64 `json:"used_gib"`
+	} `json:"hugepages"`
+}
+
+type DiskIO struct {
+	Name         string  `json:"name"`
+	ReadMBps     float64 `json:"read_mb_s"`
+	WriteMBps    float64 `json:"write_mb_s"`
+	ReadIOPS     float64 `json:"read_iops"`
+	WriteIOPS    float64 `json:"write_iops"`
+	UtilPct      float64 `json:"util_pct"`
+	ReadAwaitMs  float64 `json:"read_await_ms"`
+	WriteAwaitMs float64 `json:"write_await_ms"`
+}
+
+type Fan struct {
+	Chip    string  `json:"chip"`
+	PCIAddr string  `json:"pci_addr"`
+	Channel string  `json:"channel"`
+	Label   string  `json:"label"`
+	RPM     float64 `json:"rpm"`
+	PWMPct  float64 `json:"pwm_pct"`
+}
+
+type Voltage struct {
+	Chip    string  `json:"chip"`
+	PCIAddr string  `json:"pci_addr"`
+	Channel string  `json:"channel"`
+	Label   string  `json:"label"`
+	Volts   float64 `json:"volts"`
+}
+
+type GPU struct {
+	PCIAddr          string      `json:"pci_addr"`
+	Card             string      `json:"card"`
+	Name             string      `json:"name"`
+	EdgeC            float64     `json:"edge_c"`
+	HotspotC         float64     `json:"hotspot_c"`
+	VramC            float64     `json:"vram_c"`
+	VramUsedGB       float64     `json:"vram_used_gb"`
+	VramTotalGB      float64     `json:"vram_total_gb"`
+	VramUsedPct      float64     `json:"vram_used_pct"`
+	PowerW           float64     `json:"power_w"`
+	PowerCapW        float64     `json:"power_cap_w"`
+	PowerCapDefaultW float64     `json:"power_cap_default_w"`
+	UtilPct          float64     `json:"util_pct"`
+	GraphicsClockMHz float64     `json:"gfx_clock_mhz"`
+	SocClockMHz      float64     `json:"soc_clock_mhz"`
+	MemClockMHz      float64     `json:"mem_clock_mhz"`
+	FanRPM           float64     `json:"fan_rpm"`
+	FanPWMPct        float64     `json:"fan_pwm_pct"`
+	Throttling       bool        `json:"throttling"`
+	ThrottleReasons  []string    `json:"throttle_reasons"`
+	Engines          []GPUEngine `json:"engines"`
+
+	// readings records which of the fields above a backend reported, so
+	// a real 0 is told apart from a missing sensor.
+	readings sensors.GPUReadings
+}
+
+type GPUEngine struct {
+	Name    string  `json:"name"`
+	BusyPct float64 `json:"busy_pct"`
+}
+
+type CPUTimes struct {
+	UserPct   float64 `json:"user_pct"`
+	SystemPct float64 `json:"system_pct"`
+	IOWaitPct float64 `json:"iowait_pct"`
+	IRQPct    float64 `json:"irq_pct"`
+	StealPct  float64 `json:"steal_pct"`
+}
+
+type CPUCore struct {
+	ID      int     `json:"id"`
+	UtilPct float64 `json:"util_pct"`
+	CPUTimes
+}
+
+type CPUPowerDomain struct {
+	Zone   string  `json:"zone"`
+	Name   string  `json:"name"`
+	PowerW float64 `json:"power_w"`
+}
+
+// CPUThrottle details cpu.throttling. Source is "thermal_throttle" (Intel
+// event counters) or "frequency" (busy cores far below max clock), and
+// Events the number of throttle events in the last interval.
+type CPUThrottle struct {
+	Source       string            `json:"source"`
+	Events       uint64            `json:"events"`
+	PackageCount uint64            `json:"package_count"`
+	Cores        []CPUCoreThrottle `json:"cores"`
+}
+
+type CPUCoreThrottle struct {
+	ID         int    `json:"id"`
+	Count      uint64 `json:"count"`
+	Throttling bool   `json:"throttling"`
+}
+
+type CPUFreq struct {
+	AvgMHz                      float64       `json:"avg_mhz"`
+	MaxMHz                      float64       `json:"max_mhz"`
+	Governor                    string        `json:"governor"`
+	EnergyPerformancePreference string        `json:"energy_performance_preference"`
+	Cores                       []CPUCoreFreq `json:"cores"`
+}
+
+type CPUCoreFreq struct {
+	ID      int     `json:"id"`
+	CurMHz  float64 `json:"cur_mhz"`
+	MinMHz  float64 `json:"min_mhz"`
+	MaxMHz  float64 `json:"max_mhz"`
+	BaseMHz float64 `json:"base_mhz"`
 }
 
 func New(s *server.Server, opts ...Option) *Service {
@@ -89,91 +380,267 @@ func New(s *server.Server, opts ...Option) *Service {
 		svc.sampleInterval = time.Second
 	}
 
-	if svc.cpuSampler == nil {
-		svc.cpuSampler = sensors.NewCPUBusySampler(svc.sampleInterval)
+	svc.registry = NewRegistry()
+	for _, src := range append(svc.builtinSources(), svc.sources...) {
+		if err := svc.registry.Register(src); err != nil {
+			log.Printf("warning: sensor source skipped: %v", err)
+		}
+	}
+	svc.Start(s.Context())
+	s.OnShutdown(svc.Stop)
+	return svc
+}
+
+// Start launches the samplers until ctx is cancelled or Stop is called.
+func (m *Service) Start(ctx context.Context) {
+	m.registry.Start(ctx)
+}
+
+// Stop stops the samplers and waits for their goroutines to return.
+func (m *Service) Stop() {
+	m.registry.Stop()
+	// The process sampler may have been started by /api/processes rather
+	// than through the registry.
+	if m.processes != nil {
+		m.processes.Stop()
+	}
+}
+
+func WithSampleInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.sampleInterval = interval
+	}
+}
+
+// WithRoot points the samplers at alternate procfs/sysfs trees, e.g. the
+// host's /proc and /sys bind-mounted into a container.
+func WithRoot(root sensors.Root) Option {
+	return func(s *Service) {
+		s.root = root
+	}
+}
+
+// WithPrimaryGPU picks the GPU reported in the flat gpu block, by PCI
+// address (0000:03:00.0) or DRM card name (card1). Empty or unknown values
+// fall back to the first GPU by PCI address.
+func WithPrimaryGPU(id string) Option {
+	return func(s *Service) {
+		s.primaryGPU = id
 	}
-	if svc.cpuPower == nil {
-		svc.cpuPower = sensors.NewCPUPowerSampler(svc.sampleInterval)
+}
+
+// WithGPUBackend limits GPU sampling to one backend (GPUBackendAMDGPU,
+// GPUBackendNvidia or GPUBackendIntel). GPUBackendAuto, the default, starts every backend and
+// lets each one find its own cards.
+func WithGPUBackend(backend string) Option {
+	return func(s *Service) {
+		s.gpuBackend = backend
+	}
+}
+
+// WithAllDisks also reports partitions and loop/ram/zram devices in the
+// disks section, which by default lists whole disks only.
+func WithAllDisks(all bool) Option {
+	return func(s *Service) {
+		s.allDisks = all
+	}
+}
+
+// WithAllNetInterfaces also reports loopback, bridges and veth pairs in the
+// net section.
+func WithAllNetInterfaces(all bool) Option {
+	return func(s *Service) {
+		s.allNetIfaces = all
 	}
-	if svc.ramSampler == nil {
-		svc.ramSampler = sensors.NewSystemRAMSampler(svc.sampleInterval)
+}
+
+// WithSmartctl also polls `smartctl -j` for NVMe/SATA health (percentage
+// used, media errors, available spare) in the drives section.
+func WithSmartctl(enabled bool) Option {
+	return func(s *Service) {
+		s.smartctl = enabled
 	}
-	if svc.sensorsSampler == nil {
-		svc.sensorsSampler = sensors.NewLmSensorsSampler(svc.sampleInterval)
+}
+
+// WithProcessSampling walks /proc from startup and enables the processes
+// WS topic. It is off by default because it reads every process's stat,
+// status and DRM fdinfo each interval; /api/processes then starts the walk
+// on its first request.
+func WithProcessSampling(enabled bool) Option {
+	return func(s *Service) {
+		s.processSample = enabled
 	}
-	if svc.gpuBusySampler == nil {
-		svc.gpuBusySampler = sensors.NewGPUBusySampler(svc.sampleInterval)
+}
+
+// WithProcessTopN sets how many processes each list of /api/processes
+// holds (default sensors.DefaultProcessTopN).
+func WithProcessTopN(n int) Option {
+	return func(s *Service) {
+		s.processTopN = n
 	}
-	if svc.gpuVRAMSampler == nil {
-		svc.gpuVRAMSampler = sensors.NewGPUVRAMSampler(svc.sampleInterval)
+}
+
+// WithFilesystemFilter picks which mounts are reported under filesystems
+// and on /api/filesystems. A filter without ExcludeTypes skips
+// sensors.DefaultFSExcludeTypes.
+func WithFilesystemFilter(filter sensors.FSFilter) Option {
+	return func(s *Service) {
+		s.fsFilter = filter
 	}
+}
 
-	return newWithDeps(
-		s,
-		svc.sampleInterval,
-		svc.cpuSampler,
-		svc.cpuPower,
-		svc.ramSampler,
-		svc.sensorsSampler,
-		svc.gpuBusySampler,
-		svc.gpuVRAMSampler,
-	)
+// WithCgroupPaths sets the cgroup v2 groups, relative to /sys/fs/cgroup,
+// served on /api/cgroups. Globs such as system.slice/docker-*.scope are
+// expanded every sample. The default is sensors.DefaultCgroupPaths.
+func WithCgroupPaths(paths []string) Option {
+	return func(s *Service) {
+		s.cgroupPaths = paths
+	}
 }
 
-func WithSampleInterval(interval time.Duration) Option {
+// WithSource registers an extra sensor source after the built-in ones.
+// Its samplers are started and stopped with the service, and its readings
+// show on /api/sensors and its sections on /metrics.
+func WithSource(src Source) Option {
 	return func(s *Service) {
-		s.sampleInterval = interval
+		s.sources = append(s.sources, src)
 	}
 }
 
-func newWithDeps(
-	s *server.Server,
-	sampleInterval time.Duration,
-	cpuSampler cpuBusyReader,
-	cpuPower cpuPowerReader,
-	ramSampler ramReader,
-	sensorsSampler lmSensorsReader,
-	gpuBusySampler gpuBusyReader,
-	gpuVRAMSampler gpuVRAMReader,
-) *Service {
-	return &Service{
+// newWithSources builds a Service over the given sources without starting
+// them.
+func newWithSources(s *server.Server, sampleInterval time.Duration, sources ...Source) *Service {
+	m := &Service{
 		Server:         s,
 		sampleInterval: sampleInterval,
-		cpuSampler:     cpuSampler,
-		cpuPower:       cpuPower,
-		ramSampler:     ramSampler,
-		sensorsSampler: sensorsSampler,
-		gpuBusySampler: gpuBusySampler,
-		gpuVRAMSampler: gpuVRAMSampler,
+		registry:       NewRegistry(),
 	}
+	for _, src := range sources {
+		if err := m.registry.Register(src); err != nil {
+			log.Printf("warning: sensor source skipped: %v", err)
+		}
+	}
+
+	return m
 }
 
 func (m *Service) buildSnapshot() Snapshot {
-	var resp Snapshot
-
-	sensorSnapshot := m.sensorsSampler.Snapshot()
-	resp.CPU.TempC = sensorSnapshot.CPUTempC
-	resp.CPU.PackageTempC = sensorSnapshot.CPUPackageTempC
-	resp.GPU.EdgeC = sensorSnapshot.GPUEdgeC
-	resp.GPU.HotspotC = sensorSnapshot.GPUHotspotC
-	resp.GPU.VramC = sensorSnapshot.GPUVramC
-	resp.GPU.PowerW = sensorSnapshot.GPUPowerW
-
-	resp.CPU.UtilPct = m.cpuSampler.Snapshot().UtilPct
-	resp.CPU.PowerW = m.cpuPower.Snapshot().PowerW
-	resp.GPU.UtilPct = m.gpuBusySampler.Snapshot().UtilPct
-	gpuVRAMSnapshot := m.gpuVRAMSampler.Snapshot()
-	resp.GPU.VramUsedGB = gpuVRAMSnapshot.UsedGB
-	resp.GPU.VramTotalGB = gpuVRAMSnapshot.TotalGB
-	resp.GPU.VramUsedPct = gpuVRAMSnapshot.UsedPct
-
-	ramSnapshot, err := m.ramSampler.Snapshot()
-	if err == nil {
-		resp.RAM.TotalGB = ramSnapshot.TotalGB
-		resp.RAM.UsedGB = ramSnapshot.UsedGB
-		resp.RAM.AvailGB = ramSnapshot.AvailGB
-		resp.RAM.UsedPct = ramSnapshot.UsedPct
+	return m.registry.Snapshot()
+}
+
+// Sensors is the registry-backed view served on /api/sensors: every metric
+// the providers on this machine report, each under a stable ID.
+type Sensors struct {
+	Providers []string `json:"providers"`
+	Metrics   []Metric `json:"metrics"`
+}
+
+func (m *Service) buildSensors(prefix string) Sensors {
+	return Sensors{
+		Providers: m.registry.Providers(),
+		Metrics:   m.registry.Collect(prefix),
+	}
+}
+
+// buildFilesystems reads the filesystem sampler alone rather than building
+// the whole Snapshot.
+func (m *Service) buildFilesystems() []Filesystem {
+	if m.filesystems == nil {
+		return []Filesystem{}
+	}
+
+	return filesystemList(m.filesystems.Snapshot())
+}
+
+func (m *Service) buildCgroups() Cgroups {
+	resp := Cgroups{Groups: []Cgroup{}}
+	if m.cgroups == nil {
+		return resp
+	}
+
+	for _, group := range m.cgroups.Snapshot().Groups {
+		resp.Groups = append(resp.Groups, Cgroup{
+			Path:           group.Path,
+			CPUPct:         group.CPUPct,
+			MemoryMiB:      group.MemoryMiB,
+			MemoryMaxMiB:   group.MemoryMaxMiB,
+			MemoryPct:      group.MemoryPct,
+			ReadBytesPerS:  group.ReadBytesPerSec,
+			WriteBytesPerS: group.WriteBytesPerSec,
+			ReadIOPS:       group.ReadOpsPerSec,
+			WriteIOPS:      group.WriteOpsPerSec,
+		})
 	}
 
 	return resp
 }
+
+func (m *Service) buildProcesses() Processes {
+	resp := Processes{ByCPU: []Process{}, ByRSS: []Process{}, ByGPU: []Process{}}
+	if m.processes == nil {
+		return resp
+	}
+	// A no-op once running. Without process sampling this is the first
+	// request, and the lists fill in after two walks.
+	m.processes.Start(m.Context())
+
+	list := func(dst []Process, src []sensors.Process) []Process {
+		for _, p := range src {
+			dst = append(dst, Process{
+				PID:     p.PID,
+				Name:    p.Name,
+				CPUPct:  p.CPUPct,
+				RSSMiB:  p.RSSMiB,
+				GPUPct:  p.GPUPct,
+				VRAMMiB: p.VRAMMiB,
+			})
+		}
+		return dst
+	}
+
+	snapshot := m.processes.Snapshot()
+	resp.ByCPU = list(resp.ByCPU, snapshot.ByCPU)
+	resp.ByRSS = list(resp.ByRSS, snapshot.ByRSS)
+	resp.ByGPU = list(resp.ByGPU, snapshot.ByGPU)
+
+	return resp
+}
+
+func cpuTimes(t sensors.CPUTimesPct) CPUTimes {
+	return CPUTimes{
+		UserPct:   t.UserPct,
+		SystemPct: t.SystemPct,
+		IOWaitPct: t.IOWaitPct,
+		IRQPct:    t.IRQPct,
+		StealPct:  t.StealPct,
+	}
+}
+
+func cpuFreq(f sensors.CPUFreqSnapshot) CPUFreq {
+	freq := CPUFreq{
+		AvgMHz:                      f.AvgMHz,
+		MaxMHz:                      f.MaxMHz,
+		Governor:                    f.Governor,
+		EnergyPerformancePreference: f.EnergyPerformancePreference,
+		Cores:                       make([]CPUCoreFreq, 0, len(f.Cores)),
+	}
+	for _, core := range f.Cores {
+		freq.Cores = append(freq.Cores, CPUCoreFreq{
+			ID:      core.ID,
+			CurMHz:  core.CurMHz,
+			MinMHz:  core.MinMHz,
+			MaxMHz:  core.MaxMHz,
+			BaseMHz: core.BaseMHz,
+		})
+	}
+
+	return freq
+}
+
+func pressure(p sensors.Pressure) Pressure {
+	line := func(l sensors.PressureLine) PressureLine {
+		return PressureLine{Avg10: l.Avg10, Avg60: l.Avg60, Avg300: l.Avg300, StallPct: l.StallPct}
+	}
+
+	return Pressure{Some: line(p.Some), Full: line(p.Full)}
+}
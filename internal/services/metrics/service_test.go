@@ -1,30 +1,57 @@
 package metrics
 
 import (
+	"context"
 	"errors"
+	"reflect"
 	"sensorpanel/internal/lib/sensors"
 	"sensorpanel/internal/server"
 	"testing"
 	"time"
 )
 
+// noopSampler gives the fakes the sensors.Sampler methods the readers
+// require.
+type noopSampler struct{}
+
+func (noopSampler) Start(context.Context) {}
+func (noopSampler) Stop()                 {}
+
+// section returns the key section of s, failing the test when it is
+// missing or of another type.
+func section[T any](t *testing.T, s Snapshot, key string) T {
+	t.Helper()
+
+	v, ok := s[key].(T)
+	if !ok {
+		t.Fatalf("section %q got %#v", key, s[key])
+	}
+
+	return v
+}
+
 type fakeCPUBusy struct {
-	util float64
+	noopSampler
+	util  float64
+	cores []sensors.CPUCoreBusy
 }
 
 func (f fakeCPUBusy) Snapshot() sensors.CPUBusySnapshot {
-	return sensors.CPUBusySnapshot{UtilPct: f.util}
+	return sensors.CPUBusySnapshot{UtilPct: f.util, Cores: f.cores}
 }
 
 type fakeCPUPower struct {
-	power float64
+	noopSampler
+	power   float64
+	domains []sensors.CPUPowerDomain
 }
 
 func (f fakeCPUPower) Snapshot() sensors.CPUPowerSnapshot {
-	return sensors.CPUPowerSnapshot{PowerW: f.power}
+	return sensors.CPUPowerSnapshot{PowerW: f.power, Domains: f.domains}
 }
 
 type fakeRAM struct {
+	noopSampler
 	snapshot sensors.SystemRAMSnapshot
 	err      error
 }
@@ -34,6 +61,7 @@ func (f fakeRAM) Snapshot() (sensors.SystemRAMSnapshot, error) {
 }
 
 type fakeLmSensors struct {
+	noopSampler
 	snapshot sensors.LmSensorsSnapshot
 }
 
@@ -42,14 +70,16 @@ func (f fakeLmSensors) Snapshot() sensors.LmSensorsSnapshot {
 }
 
 type fakeGPUBusy struct {
-	util float64
+	noopSampler
+	gpus []sensors.GPUBusy
 }
 
 func (f fakeGPUBusy) Snapshot() sensors.GPUBusySnapshot {
-	return sensors.GPUBusySnapshot{UtilPct: f.util}
+	return sensors.GPUBusySnapshot{GPUs: f.gpus}
 }
 
 type fakeGPUVRAM struct {
+	noopSampler
 	snapshot sensors.GPUVRAMSnapshot
 }
 
@@ -58,68 +88,976 @@ func (f fakeGPUVRAM) Snapshot() sensors.GPUVRAMSnapshot {
 }
 
 func TestBuildSnapshotMapsAllValues(t *testing.T) {
-	m := newWithDeps(
-		&server.Server{},
-		time.Second,
-		fakeCPUBusy{util: 33.3},
-		fakeCPUPower{power: 45.6},
-		fakeRAM{snapshot: sensors.SystemRAMSnapshot{TotalGB: 32, UsedGB: 14, AvailGB: 18, UsedPct: 43.75}},
-		fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{CPUTempC: 70.1, CPUPackageTempC: 67.9, GPUEdgeC: 61.2, GPUHotspotC: 75.3, GPUVramC: 79.4, GPUPowerW: 210.5}},
-		fakeGPUBusy{util: 88.8},
-		fakeGPUVRAM{snapshot: sensors.GPUVRAMSnapshot{UsedGB: 7.5, TotalGB: 16, UsedPct: 46.875}},
+	lm := fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{CPUTempC: 70.1, CPUPackageTempC: 67.9, HasCPUTemp: true, GPUs: []sensors.GPUSensors{
+		{PCIAddr: "0000:03:00.0", Readings: sensors.GPUEdgeTemp | sensors.GPUHotspotTemp | sensors.GPUMemTemp | sensors.GPUPower, EdgeC: 61.2, HotspotC: 75.3, VramC: 79.4, PowerW: 210.5},
+	}}}
+	m := newWithSources(&server.Server{}, time.Second,
+		cpuSource(cpuReaders{busy: fakeCPUBusy{util: 33.3}, power: fakeCPUPower{power: 45.6}, temps: lm}),
+		memorySource(fakeRAM{snapshot: sensors.SystemRAMSnapshot{TotalGiB: 32, UsedGiB: 14, AvailGiB: 18, UsedPct: 43.75, SwapTotalGiB: 8, SwapUsedGiB: 2, SwapUsedPct: 25, Zram: sensors.ZramSnapshot{Devices: 1, OrigGiB: 4, ComprGiB: 1, Ratio: 4}, HugePagesTotal: 512, HugePageSizeKiB: 2048}}),
+		hwmonSource(lm, nil),
+		gpuSource(gpuReaders{
+			hwmon: lm,
+			busy:  fakeGPUBusy{gpus: []sensors.GPUBusy{{PCIAddr: "0000:03:00.0", Card: "card1", UtilPct: 88.8}}},
+			vram: fakeGPUVRAM{snapshot: sensors.GPUVRAMSnapshot{GPUs: []sensors.GPUVRAM{
+				{PCIAddr: "0000:03:00.0", Card: "card1", UsedGB: 7.5, TotalGB: 16, UsedPct: 46.875},
+			}}},
+		}, ""),
 	)
 
 	s := m.buildSnapshot()
+	cpu, ram := section[CPU](t, s, "cpu"), section[RAM](t, s, "ram")
+	gpu, gpus := section[GPU](t, s, "gpu"), section[[]GPU](t, s, "gpus")
 
-	if s.CPU.TempC != 70.1 {
-		t.Fatalf("CPU temp mismatch: got %v", s.CPU.TempC)
+	if cpu.TempC != 70.1 {
+		t.Fatalf("CPU temp mismatch: got %v", cpu.TempC)
 	}
-	if s.CPU.PackageTempC != 67.9 {
-		t.Fatalf("CPU package temp mismatch: got %v", s.CPU.PackageTempC)
+	if cpu.PackageTempC != 67.9 {
+		t.Fatalf("CPU package temp mismatch: got %v", cpu.PackageTempC)
 	}
-	if s.CPU.UtilPct != 33.3 {
-		t.Fatalf("CPU util mismatch: got %v", s.CPU.UtilPct)
+	if cpu.UtilPct != 33.3 {
+		t.Fatalf("CPU util mismatch: got %v", cpu.UtilPct)
 	}
-	if s.CPU.PowerW != 45.6 {
-		t.Fatalf("CPU power mismatch: got %v", s.CPU.PowerW)
+	if cpu.PowerW != 45.6 {
+		t.Fatalf("CPU power mismatch: got %v", cpu.PowerW)
 	}
 
-	if s.RAM.TotalGB != 32 || s.RAM.UsedGB != 14 || s.RAM.AvailGB != 18 || s.RAM.UsedPct != 43.75 {
-		t.Fatalf("RAM snapshot mismatch: got %+v", s.RAM)
+	if ram.TotalGiB != 32 || ram.UsedGiB != 14 || ram.AvailGiB != 18 || ram.UsedPct != 43.75 {
+		t.Fatalf("RAM snapshot mismatch: got %+v", ram)
+	}
+	if ram.Swap.UsedGiB != 2 || ram.Swap.UsedPct != 25 || ram.Zram.Ratio != 4 || ram.HugePages.Total != 512 || ram.HugePages.SizeKiB != 2048 {
+		t.Fatalf("RAM swap/zram/hugepages mismatch: got %+v", ram)
 	}
 
-	if s.GPU.EdgeC != 61.2 || s.GPU.HotspotC != 75.3 || s.GPU.VramC != 79.4 {
-		t.Fatalf("GPU temps mismatch: got %+v", s.GPU)
+	if gpu.EdgeC != 61.2 || gpu.HotspotC != 75.3 || gpu.VramC != 79.4 {
+		t.Fatalf("GPU temps mismatch: got %+v", gpu)
+	}
+	if gpu.PowerW != 210.5 || gpu.UtilPct != 88.8 {
+		t.Fatalf("GPU util/power mismatch: got %+v", gpu)
 	}
-	if s.GPU.PowerW != 210.5 || s.GPU.UtilPct != 88.8 {
-		t.Fatalf("GPU util/power mismatch: got %+v", s.GPU)
+	if gpu.VramUsedGB != 7.5 || gpu.VramTotalGB != 16 || gpu.VramUsedPct != 46.875 {
+		t.Fatalf("GPU VRAM mismatch: got %+v", gpu)
 	}
-	if s.GPU.VramUsedGB != 7.5 || s.GPU.VramTotalGB != 16 || s.GPU.VramUsedPct != 46.875 {
-		t.Fatalf("GPU VRAM mismatch: got %+v", s.GPU)
+	if len(gpus) != 1 || !reflect.DeepEqual(gpus[0], gpu) || gpu.PCIAddr != "0000:03:00.0" || gpu.Card != "card1" {
+		t.Fatalf("GPU list mismatch: got %+v", gpus)
 	}
 }
 
 func TestBuildSnapshotKeepsRAMZeroWhenSamplerFails(t *testing.T) {
-	m := newWithDeps(
-		&server.Server{},
-		time.Second,
-		fakeCPUBusy{util: 10},
-		fakeCPUPower{power: 20},
-		fakeRAM{err: errors.New("ram unavailable")},
-		fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{CPUTempC: 50, CPUPackageTempC: 48, GPUEdgeC: 55, GPUPowerW: 100}},
-		fakeGPUBusy{util: 30},
-		fakeGPUVRAM{snapshot: sensors.GPUVRAMSnapshot{UsedGB: 4, TotalGB: 8, UsedPct: 50}},
+	lm := fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{CPUTempC: 50, CPUPackageTempC: 48, HasCPUTemp: true, GPUs: []sensors.GPUSensors{
+		{PCIAddr: "0000:03:00.0", Readings: sensors.GPUEdgeTemp | sensors.GPUPower, EdgeC: 55, PowerW: 100},
+	}}}
+	m := newWithSources(&server.Server{}, time.Second,
+		cpuSource(cpuReaders{busy: fakeCPUBusy{util: 10}, power: fakeCPUPower{power: 20}, temps: lm}),
+		memorySource(fakeRAM{err: errors.New("ram unavailable")}),
+		hwmonSource(lm, nil),
+		gpuSource(gpuReaders{
+			hwmon: lm,
+			busy:  fakeGPUBusy{gpus: []sensors.GPUBusy{{PCIAddr: "0000:03:00.0", UtilPct: 30}}},
+			vram: fakeGPUVRAM{snapshot: sensors.GPUVRAMSnapshot{GPUs: []sensors.GPUVRAM{
+				{PCIAddr: "0000:03:00.0", UsedGB: 4, TotalGB: 8, UsedPct: 50},
+			}}},
+		}, ""),
 	)
 
 	s := m.buildSnapshot()
+	cpu, ram, gpu := section[CPU](t, s, "cpu"), section[RAM](t, s, "ram"), section[GPU](t, s, "gpu")
+
+	if ram.TotalGiB != 0 || ram.UsedGiB != 0 || ram.AvailGiB != 0 || ram.UsedPct != 0 {
+		t.Fatalf("expected zero RAM when sampler errors, got %+v", ram)
+	}
+	if cpu.TempC != 50 || cpu.PackageTempC != 48 || cpu.UtilPct != 10 || cpu.PowerW != 20 {
+		t.Fatalf("non-RAM fields should still map, got CPU=%+v", cpu)
+	}
+	if gpu.UtilPct != 30 || gpu.PowerW != 100 || gpu.VramUsedPct != 50 {
+		t.Fatalf("non-RAM fields should still map, got GPU=%+v", gpu)
+	}
+}
+
+func TestBuildSnapshotMapsCPUCores(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, cpuSource(cpuReaders{
+		busy: fakeCPUBusy{util: 12, cores: []sensors.CPUCoreBusy{
+			{ID: 0, UtilPct: 4},
+			{ID: 7, UtilPct: 100, CPUTimesPct: sensors.CPUTimesPct{UserPct: 97, SystemPct: 3}},
+		}},
+	}))
+
+	s := m.buildSnapshot()
+	cpu := section[CPU](t, s, "cpu")
+
+	if cpu.UtilPct != 12 || len(cpu.Cores) != 2 {
+		t.Fatalf("CPU cores mismatch: got %+v", cpu)
+	}
+	pegged := cpu.Cores[1]
+	if pegged.ID != 7 || pegged.UtilPct != 100 || pegged.UserPct != 97 || pegged.SystemPct != 3 {
+		t.Fatalf("pegged core mismatch: got %+v", pegged)
+	}
+}
+
+type fakeCPUFreq struct {
+	noopSampler
+	snapshot sensors.CPUFreqSnapshot
+}
+
+func (f fakeCPUFreq) Snapshot() sensors.CPUFreqSnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMapsCPUFreq(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, cpuSource(cpuReaders{
+		freq: fakeCPUFreq{snapshot: sensors.CPUFreqSnapshot{
+			AvgMHz:   3200,
+			MaxMHz:   5050,
+			Governor: "performance",
+			Cores:    []sensors.CPUCoreFreq{{ID: 3, CurMHz: 5050, MaxMHz: 5100}},
+		}},
+	}))
+
+	s := m.buildSnapshot()
+	cpu := section[CPU](t, s, "cpu")
+
+	if cpu.Freq.AvgMHz != 3200 || cpu.Freq.MaxMHz != 5050 || cpu.Freq.Governor != "performance" {
+		t.Fatalf("CPU freq mismatch: got %+v", cpu.Freq)
+	}
+	if len(cpu.Freq.Cores) != 1 || cpu.Freq.Cores[0].ID != 3 || cpu.Freq.Cores[0].MaxMHz != 5100 {
+		t.Fatalf("CPU freq cores mismatch: got %+v", cpu.Freq.Cores)
+	}
+}
+
+func TestBuildSnapshotMapsCPUPowerDomains(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, cpuSource(cpuReaders{
+		power: fakeCPUPower{power: 95, domains: []sensors.CPUPowerDomain{
+			{Zone: "intel-rapl:0", Name: "package-0", PowerW: 60},
+			{Zone: "intel-rapl:1", Name: "package-1", PowerW: 35},
+			{Zone: "intel-rapl:0:2", Name: "dram", PowerW: 4.5},
+		}},
+	}))
+
+	s := m.buildSnapshot()
+	cpu := section[CPU](t, s, "cpu")
+
+	if cpu.PowerW != 95 || len(cpu.PowerDomains) != 3 {
+		t.Fatalf("CPU power mismatch: got %v %+v", cpu.PowerW, cpu.PowerDomains)
+	}
+	if dram := cpu.PowerDomains[2]; dram.Zone != "intel-rapl:0:2" || dram.Name != "dram" || dram.PowerW != 4.5 {
+		t.Fatalf("dram domain mismatch: got %+v", dram)
+	}
+}
+
+func multiGPUSource(primary string) Source {
+	return gpuSource(gpuReaders{
+		hwmon: fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{GPUs: []sensors.GPUSensors{
+			{PCIAddr: "0000:7c:00.0", EdgeC: 41},
+			{PCIAddr: "0000:03:00.0", EdgeC: 68, PowerW: 280},
+		}}},
+		busy: fakeGPUBusy{gpus: []sensors.GPUBusy{
+			{PCIAddr: "0000:03:00.0", Card: "card1", UtilPct: 99},
+			{PCIAddr: "0000:7c:00.0", Card: "card0", UtilPct: 2},
+		}},
+		vram: fakeGPUVRAM{snapshot: sensors.GPUVRAMSnapshot{GPUs: []sensors.GPUVRAM{
+			{PCIAddr: "0000:7c:00.0", Card: "card0", UsedGB: 0.5, TotalGB: 2, UsedPct: 25},
+		}}},
+	}, primary)
+}
+
+func TestBuildSnapshotMergesGPUsByPCIAddr(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, multiGPUSource(""))
+
+	s := m.buildSnapshot()
+	gpu, gpus := section[GPU](t, s, "gpu"), section[[]GPU](t, s, "gpus")
+
+	if len(gpus) != 2 {
+		t.Fatalf("expected 2 GPUs, got %+v", gpus)
+	}
+	dgpu, igpu := gpus[0], gpus[1]
+	if dgpu.PCIAddr != "0000:03:00.0" || dgpu.Card != "card1" || dgpu.EdgeC != 68 || dgpu.UtilPct != 99 || dgpu.VramTotalGB != 0 {
+		t.Fatalf("dGPU mismatch: got %+v", dgpu)
+	}
+	if igpu.PCIAddr != "0000:7c:00.0" || igpu.Card != "card0" || igpu.EdgeC != 41 || igpu.UtilPct != 2 || igpu.VramTotalGB != 2 {
+		t.Fatalf("iGPU mismatch: got %+v", igpu)
+	}
+	if !reflect.DeepEqual(gpu, dgpu) {
+		t.Fatalf("expected first GPU by PCI address as primary, got %+v", gpu)
+	}
+}
+
+func TestBuildSnapshotUsesConfiguredPrimaryGPU(t *testing.T) {
+	for _, id := range []string{"0000:7c:00.0", "card0"} {
+		m := newWithSources(&server.Server{}, time.Second, multiGPUSource(id))
+
+		s := m.buildSnapshot()
+		gpu := section[GPU](t, s, "gpu")
+
+		if gpu.PCIAddr != "0000:7c:00.0" || gpu.UtilPct != 2 {
+			t.Fatalf("primary %q: got %+v", id, gpu)
+		}
+	}
+}
+
+type fakeNvidiaSMI struct {
+	noopSampler
+	snapshot sensors.NvidiaSMISnapshot
+}
+
+func (f fakeNvidiaSMI) Snapshot() sensors.NvidiaSMISnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMapsNvidiaGPU(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, gpuSource(gpuReaders{
+		nvidia: fakeNvidiaSMI{snapshot: sensors.NvidiaSMISnapshot{GPUs: []sensors.NvidiaGPU{{
+			PCIAddr:          "0000:01:00.0",
+			Name:             "NVIDIA GeForce RTX 4080",
+			UtilPct:          97,
+			VramUsedGB:       12,
+			VramTotalGB:      16,
+			VramUsedPct:      75,
+			TempC:            71,
+			PowerW:           301.5,
+			GraphicsClockMHz: 2715,
+			MemClockMHz:      11201,
+		}}}},
+	}, ""))
+
+	s := m.buildSnapshot()
+	gpu, gpus := section[GPU](t, s, "gpu"), section[[]GPU](t, s, "gpus")
+
+	if len(gpus) != 1 || !reflect.DeepEqual(gpu, gpus[0]) {
+		t.Fatalf("expected the NVIDIA card as the only and primary GPU, got %+v", gpus)
+	}
+	if gpu.PCIAddr != "0000:01:00.0" || gpu.Name != "NVIDIA GeForce RTX 4080" || gpu.EdgeC != 71 || gpu.UtilPct != 97 {
+		t.Fatalf("NVIDIA GPU mismatch: got %+v", gpu)
+	}
+	if gpu.VramUsedPct != 75 || gpu.PowerW != 301.5 || gpu.GraphicsClockMHz != 2715 || gpu.MemClockMHz != 11201 {
+		t.Fatalf("NVIDIA GPU readings mismatch: got %+v", gpu)
+	}
+}
+
+func TestGPUBackendEnabled(t *testing.T) {
+	tests := []struct {
+		backend string
+		amdgpu  bool
+		nvidia  bool
+		intel   bool
+	}{
+		{backend: "", amdgpu: true, nvidia: true, intel: true},
+		{backend: GPUBackendAuto, amdgpu: true, nvidia: true, intel: true},
+		{backend: GPUBackendAMDGPU, amdgpu: true, nvidia: false, intel: false},
+		{backend: GPUBackendNvidia, amdgpu: false, nvidia: true, intel: false},
+		{backend: GPUBackendIntel, amdgpu: false, nvidia: false, intel: true},
+	}
+
+	for _, tt := range tests {
+		m := &Service{}
+		WithGPUBackend(tt.backend)(m)
+		if got := m.gpuBackendEnabled(GPUBackendAMDGPU); got != tt.amdgpu {
+			t.Fatalf("backend %q amdgpu enabled=%v, want %v", tt.backend, got, tt.amdgpu)
+		}
+		if got := m.gpuBackendEnabled(GPUBackendNvidia); got != tt.nvidia {
+			t.Fatalf("backend %q nvidia enabled=%v, want %v", tt.backend, got, tt.nvidia)
+		}
+		if got := m.gpuBackendEnabled(GPUBackendIntel); got != tt.intel {
+			t.Fatalf("backend %q intel enabled=%v, want %v", tt.backend, got, tt.intel)
+		}
+	}
+}
+
+type fakeIntelGPU struct {
+	noopSampler
+	snapshot sensors.IntelGPUSnapshot
+}
+
+func (f fakeIntelGPU) Snapshot() sensors.IntelGPUSnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMapsIntelGPUEngines(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, gpuSource(gpuReaders{
+		intel: fakeIntelGPU{snapshot: sensors.IntelGPUSnapshot{GPUs: []sensors.IntelGPU{{
+			PCIAddr:    "0000:00:02.0",
+			Card:       "card0",
+			Driver:     "i915",
+			Readings:   sensors.GPUUtil | sensors.GPUGraphicsClock,
+			UtilPct:    64,
+			ActFreqMHz: 1300,
+			Engines: []sensors.GPUEngine{
+				{Name: "render", BusyPct: 64},
+				{Name: "video", BusyPct: 12.5},
+			},
+		}}}},
+	}, ""))
+
+	s := m.buildSnapshot()
+	gpu := section[GPU](t, s, "gpu")
+
+	if gpu.PCIAddr != "0000:00:02.0" || gpu.Card != "card0" || gpu.UtilPct != 64 || gpu.GraphicsClockMHz != 1300 {
+		t.Fatalf("Intel GPU mismatch: got %+v", gpu)
+	}
+	if len(gpu.Engines) != 2 || gpu.Engines[1] != (GPUEngine{Name: "video", BusyPct: 12.5}) {
+		t.Fatalf("Intel GPU engines mismatch: got %+v", gpu.Engines)
+	}
+}
+
+type fakeAMDGPUMetrics struct {
+	noopSampler
+	snapshot sensors.AMDGPUMetricsSnapshot
+}
+
+func (f fakeAMDGPUMetrics) Snapshot() sensors.AMDGPUMetricsSnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMergesAMDGPUMetrics(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, gpuSource(gpuReaders{
+		hwmon: fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{GPUs: []sensors.GPUSensors{
+			{PCIAddr: "0000:03:00.0", Readings: sensors.GPUEdgeTemp | sensors.GPUPower, EdgeC: 61, PowerW: 250},
+		}}},
+		amdgpu: fakeAMDGPUMetrics{snapshot: sensors.AMDGPUMetricsSnapshot{GPUs: []sensors.AMDGPUMetrics{{
+			PCIAddr:        "0000:03:00.0",
+			Card:           "card1",
+			Readings:       sensors.GPUEdgeTemp | sensors.GPUHotspotTemp | sensors.GPUUtil | sensors.GPUPower | sensors.GPUGraphicsClock | sensors.GPUMemClock | sensors.GPUFan,
+			EdgeC:          62,
+			HotspotC:       81,
+			SocketPowerW:   263,
+			GfxActivityPct: 99,
+			GfxClockMHz:    2604,
+			SocClockMHz:    1200,
+			MemClockMHz:    1249,
+			FanRPM:         1630,
+			ThrottleStatus: 1,
+			Throttlers:     []string{"ppt0"},
+		}}}},
+	}, ""))
+
+	s := m.buildSnapshot()
+	gpu := section[GPU](t, s, "gpu")
+
+	if gpu.EdgeC != 61 || gpu.PowerW != 250 {
+		t.Fatalf("hwmon readings should win, got %+v", gpu)
+	}
+	if gpu.HotspotC != 81 || gpu.UtilPct != 99 || gpu.Card != "card1" {
+		t.Fatalf("gpu_metrics should fill gaps, got %+v", gpu)
+	}
+	if gpu.GraphicsClockMHz != 2604 || gpu.SocClockMHz != 1200 || gpu.MemClockMHz != 1249 || gpu.FanRPM != 1630 {
+		t.Fatalf("clocks/fan mismatch, got %+v", gpu)
+	}
+	if !gpu.Throttling || !reflect.DeepEqual(gpu.ThrottleReasons, []string{"ppt0"}) {
+		t.Fatalf("throttling mismatch, got %+v", gpu)
+	}
+}
+
+type fakeGPUClock struct {
+	noopSampler
+	snapshot sensors.GPUClockSnapshot
+}
+
+func (f fakeGPUClock) Snapshot() sensors.GPUClockSnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMapsGPUClocksAndPowerCap(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, gpuSource(gpuReaders{
+		hwmon: fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{GPUs: []sensors.GPUSensors{
+			{PCIAddr: "0000:03:00.0", Readings: sensors.GPUPower | sensors.GPUFan, PowerW: 262.8, PowerCapW: 263, PowerCapDefaultW: 303, FanRPM: 1450, FanPWMPct: 40},
+		}}},
+		clock: fakeGPUClock{snapshot: sensors.GPUClockSnapshot{GPUs: []sensors.GPUClock{
+			{PCIAddr: "0000:03:00.0", Card: "card1", Readings: sensors.GPUGraphicsClock | sensors.GPUMemClock, ShaderClockMHz: 2604, MemClockMHz: 1249},
+		}}},
+	}, ""))
+
+	s := m.buildSnapshot()
+	gpu := section[GPU](t, s, "gpu")
+
+	if gpu.GraphicsClockMHz != 2604 || gpu.MemClockMHz != 1249 || gpu.Card != "card1" {
+		t.Fatalf("GPU clocks mismatch: got %+v", gpu)
+	}
+	if gpu.PowerCapW != 263 || gpu.PowerCapDefaultW != 303 || gpu.FanRPM != 1450 || gpu.FanPWMPct != 40 {
+		t.Fatalf("GPU power cap/fan mismatch: got %+v", gpu)
+	}
+}
+
+type fakeFans struct {
+	noopSampler
+	fans []sensors.Fan
+}
+
+func (f fakeFans) Snapshot() sensors.FanSnapshot {
+	return sensors.FanSnapshot{Fans: f.fans}
+}
+
+func TestBuildSnapshotMapsFans(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, hwmonSource(nil, fakeFans{fans: []sensors.Fan{
+		{Chip: "nct6798", Channel: "fan2", Label: "CPU Fan", RPM: 1120, PWMPct: 100},
+		{Chip: "amdgpu", PCIAddr: "0000:03:00.0", Channel: "fan1", Label: "fan1", RPM: 1450, PWMPct: 40},
+	}}))
+
+	s := m.buildSnapshot()
+	fans := section[[]Fan](t, s, "fans")
+
+	if len(fans) != 2 {
+		t.Fatalf("expected 2 fans, got %+v", fans)
+	}
+	want := Fan{Chip: "nct6798", Channel: "fan2", Label: "CPU Fan", RPM: 1120, PWMPct: 100}
+	if fans[0] != want {
+		t.Fatalf("fan mismatch: got %+v, want %+v", fans[0], want)
+	}
+	if fans[1].PCIAddr != "0000:03:00.0" || fans[1].RPM != 1450 {
+		t.Fatalf("GPU fan mismatch: got %+v", fans[1])
+	}
+}
+
+type fakeDiskIO struct {
+	noopSampler
+	disks []sensors.DiskIO
+}
+
+func (f fakeDiskIO) Snapshot() sensors.DiskIOSnapshot {
+	return sensors.DiskIOSnapshot{Disks: f.disks}
+}
+
+func TestBuildSnapshotMapsDisks(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, diskSource(fakeDiskIO{disks: []sensors.DiskIO{
+		{Name: "nvme0n1", ReadMBps: 1850.5, WriteMBps: 12, ReadIOPS: 14000, WriteIOPS: 90, UtilPct: 97, ReadAwaitMs: 0.2, WriteAwaitMs: 0.05},
+	}}))
+
+	s := m.buildSnapshot()
+	disks := section[[]DiskIO](t, s, "disks")
+
+	want := DiskIO{Name: "nvme0n1", ReadMBps: 1850.5, WriteMBps: 12, ReadIOPS: 14000, WriteIOPS: 90, UtilPct: 97, ReadAwaitMs: 0.2, WriteAwaitMs: 0.05}
+	if len(disks) != 1 || disks[0] != want {
+		t.Fatalf("disks mismatch: got %+v, want [%+v]", disks, want)
+	}
+}
+
+type fakeNetIO struct {
+	noopSampler
+	ifaces []sensors.NetInterface
+}
+
+func (f fakeNetIO) Snapshot() sensors.NetIOSnapshot {
+	return sensors.NetIOSnapshot{Interfaces: f.ifaces}
+}
+
+func TestBuildSnapshotMapsNet(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, netSource(fakeNetIO{ifaces: []sensors.NetInterface{
+		{Name: "enp5s0", State: "up", SpeedMbps: 2500, RxBytesPerSec: 1250000, TxBytesPerSec: 48000, RxPacketsPerSec: 910, TxPacketsPerSec: 320, RxErrorsPerSec: 1},
+	}}))
+
+	s := m.buildSnapshot()
+	ifaces := section[[]NetIO](t, s, "net")
+
+	want := NetIO{Name: "enp5s0", State: "up", SpeedMbps: 2500, RxBytesPerSec: 1250000, TxBytesPerSec: 48000, RxPacketsPerSec: 910, TxPacketsPerSec: 320, RxErrorsPerSec: 1}
+	if len(ifaces) != 1 || ifaces[0] != want {
+		t.Fatalf("net mismatch: got %+v, want [%+v]", ifaces, want)
+	}
+}
+
+type fakeStorage struct {
+	noopSampler
+	drives []sensors.Drive
+}
+
+func (f fakeStorage) Snapshot() sensors.StorageSnapshot {
+	return sensors.StorageSnapshot{Drives: f.drives}
+}
+
+func TestBuildSnapshotMapsDrives(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, storageSource(fakeStorage{drives: []sensors.Drive{
+		{Name: "nvme0", Kind: sensors.DriveKindNVMe, Model: "990 PRO", TempC: 84, TempWarnC: 82, TempCritC: 85, Throttling: true, SMART: true, HealthPassed: true, PercentageUsed: 3, AvailableSparePct: 100, AvailableSpareThresholdPct: 10, WarningTempMinutes: 17},
+	}}))
+
+	s := m.buildSnapshot()
+	drives := section[[]Drive](t, s, "drives")
+
+	want := Drive{Name: "nvme0", Kind: "nvme", Model: "990 PRO", TempC: 84, TempWarnC: 82, TempCritC: 85, Throttling: true, SMART: true, HealthPassed: true, PercentageUsed: 3, AvailableSparePct: 100, AvailableSpareThresholdPct: 10, WarningTempMinutes: 17}
+	if len(drives) != 1 || drives[0] != want {
+		t.Fatalf("drives mismatch: got %+v, want [%+v]", drives, want)
+	}
+}
+
+type fakePressure struct {
+	noopSampler
+	snapshot sensors.PressureSnapshot
+}
+
+func (f fakePressure) Snapshot() sensors.PressureSnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMapsPressure(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, pressureSource(fakePressure{snapshot: sensors.PressureSnapshot{
+		CPU: sensors.Pressure{Some: sensors.PressureLine{Avg10: 4.12, Avg60: 2.3, Avg300: 0.9, StallPct: 5.1}},
+		IO: sensors.Pressure{
+			Some: sensors.PressureLine{Avg10: 1.05, StallPct: 0.8},
+			Full: sensors.PressureLine{Avg10: 0.9, StallPct: 0.7},
+		},
+	}}))
+
+	s := m.buildSnapshot()
+	pressure := section[Pressures](t, s, "pressure")
+
+	if want := (PressureLine{Avg10: 4.12, Avg60: 2.3, Avg300: 0.9, StallPct: 5.1}); pressure.CPU.Some != want {
+		t.Fatalf("cpu pressure got %+v, want %+v", pressure.CPU.Some, want)
+	}
+	if want := (PressureLine{Avg10: 0.9, StallPct: 0.7}); pressure.IO.Full != want {
+		t.Fatalf("io full pressure got %+v, want %+v", pressure.IO.Full, want)
+	}
+	if pressure.Memory != (Pressure{}) {
+		t.Fatalf("memory pressure should be zero, got %+v", pressure.Memory)
+	}
+}
+
+type fakeProcesses struct {
+	noopSampler
+	snapshot sensors.ProcessSnapshot
+}
+
+func (f fakeProcesses) Snapshot() sensors.ProcessSnapshot {
+	return f.snapshot
+}
+
+func TestBuildProcesses(t *testing.T) {
+	game := sensors.Process{PID: 4242, Name: "GameThread", CPUPct: 312.5, RSSMiB: 6120.4, GPUPct: 97, VRAMMiB: 9830}
+	m := newWithSources(&server.Server{}, time.Second)
+	m.processes = fakeProcesses{snapshot: sensors.ProcessSnapshot{
+		ByCPU: []sensors.Process{game},
+		ByGPU: []sensors.Process{game},
+	}}
+
+	p := m.buildProcesses()
+
+	want := Process{PID: 4242, Name: "GameThread", CPUPct: 312.5, RSSMiB: 6120.4, GPUPct: 97, VRAMMiB: 9830}
+	if len(p.ByCPU) != 1 || p.ByCPU[0] != want || len(p.ByGPU) != 1 || p.ByGPU[0] != want {
+		t.Fatalf("processes mismatch: got %+v, want %+v", p, want)
+	}
+	if p.ByRSS == nil || len(p.ByRSS) != 0 {
+		t.Fatalf("empty list should be non-nil, got %#v", p.ByRSS)
+	}
+}
+
+func TestBuildFrameAddsProcessesOnlyWhenRequested(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, cpuSource(cpuReaders{busy: fakeCPUBusy{}}))
+	m.processSample = true
+	m.processes = fakeProcesses{snapshot: sensors.ProcessSnapshot{ByCPU: []sensors.Process{{PID: 1, Name: "init"}}}}
+
+	if frame := m.buildFrame(parseTopics("")); frame[TopicProcesses] != nil {
+		t.Fatalf("processes should be omitted without the topic, got %+v", frame[TopicProcesses])
+	}
+
+	frame := m.buildFrame(parseTopics("gpus, processes"))
+	if p := section[Processes](t, frame, TopicProcesses); len(p.ByCPU) != 1 || p.ByCPU[0].PID != 1 {
+		t.Fatalf("processes topic got %+v", p)
+	}
+	if _, ok := frame["cpu"]; !ok {
+		t.Fatalf("frame should keep the snapshot sections, got %+v", frame)
+	}
+}
+
+func TestBuildFrameSkipsProcessesWhenSamplingDisabled(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, cpuSource(cpuReaders{busy: fakeCPUBusy{}}))
+	m.processes = fakeProcesses{snapshot: sensors.ProcessSnapshot{ByCPU: []sensors.Process{{PID: 1, Name: "init"}}}}
+
+	if frame := m.buildFrame(parseTopics("processes")); frame[TopicProcesses] != nil {
+		t.Fatalf("processes should be omitted while sampling is off, got %+v", frame[TopicProcesses])
+	}
+}
+
+type lazyProcesses struct {
+	fakeProcesses
+	started context.Context
+	stopped bool
+}
+
+func (f *lazyProcesses) Start(ctx context.Context) { f.started = ctx }
+func (f *lazyProcesses) Stop()                     { f.stopped = true }
+
+func TestBuildProcessesStartsSamplerOnDemand(t *testing.T) {
+	procs := &lazyProcesses{}
+	m := newWithSources(&server.Server{}, time.Second)
+	m.processes = procs
+
+	m.Start(context.Background())
+	if procs.started != nil {
+		t.Fatal("process sampler should not start without process sampling")
+	}
+	m.buildProcesses()
+	if procs.started == nil {
+		t.Fatal("buildProcesses should start the process sampler")
+	}
+	m.Stop()
+	if !procs.stopped {
+		t.Fatal("Stop should stop a process sampler started on demand")
+	}
+}
+
+type fakeBattery struct {
+	noopSampler
+	snapshot sensors.BatterySnapshot
+}
+
+func (f fakeBattery) Snapshot() sensors.BatterySnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMapsBattery(t *testing.T) {
+	battery := fakeBattery{snapshot: sensors.BatterySnapshot{Batteries: []sensors.Battery{
+		{Name: "BAT1", Status: "Discharging", CapacityPct: 64, PowerW: -14.2, EnergyWh: 25.6, EnergyFullWh: 40, EnergyDesignWh: 40, HealthPct: 100, TimeToEmptyMin: 108},
+	}}}
+
+	s := newWithSources(&server.Server{}, time.Second, batterySource(battery)).buildSnapshot()
+
+	want := BatteryInfo{Name: "BAT1", Status: "Discharging", CapacityPct: 64, PowerW: -14.2, EnergyWh: 25.6, EnergyFullWh: 40, EnergyDesignWh: 40, HealthPct: 100, TimeToEmptyMin: 108}
+	if got := section[Battery](t, s, "battery"); got.ACOnline || len(got.Batteries) != 1 || got.Batteries[0] != want {
+		t.Fatalf("battery mismatch: got %+v, want [%+v]", got, want)
+	}
+
+	battery = fakeBattery{snapshot: sensors.BatterySnapshot{ACOnline: true}}
+	if s := newWithSources(&server.Server{}, time.Second, batterySource(battery)).buildSnapshot(); s["battery"] != nil {
+		t.Fatalf("battery should be omitted without batteries, got %+v", s["battery"])
+	}
+}
+
+func TestBuildSensorsReportsPresentSensorsAtZero(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second,
+		storageSource(fakeStorage{drives: []sensors.Drive{
+			{Name: "nvme0", Kind: sensors.DriveKindNVMe, HasTemp: true},
+			{Name: "sdb", Kind: sensors.DriveKindSATA},
+		}}),
+		batterySource(fakeBattery{snapshot: sensors.BatterySnapshot{Batteries: []sensors.Battery{
+			{Name: "BAT0", Status: "Full", CapacityPct: 100, HasTimeEstimate: true},
+			{Name: "BAT1", Status: "Unknown", CapacityPct: 80},
+		}}}),
+	)
+
+	byID := make(map[string]Metric)
+	for _, metric := range m.buildSensors("").Metrics {
+		byID[metric.ID] = metric
+	}
+	for _, id := range []string{"drive.nvme0.temp_c", "battery.bat0.time_to_empty_min", "battery.bat0.time_to_full_min"} {
+		if got, ok := byID[id]; !ok || got.Value != 0 {
+			t.Fatalf("%s got %+v, present %v", id, got, ok)
+		}
+	}
+	for _, id := range []string{"drive.sdb.temp_c", "battery.bat1.time_to_empty_min", "battery.bat1.time_to_full_min"} {
+		if got, ok := byID[id]; ok {
+			t.Fatalf("%s should be missing without a sensor, got %+v", id, got)
+		}
+	}
+}
+
+type fakeThermal struct {
+	noopSampler
+	snapshot sensors.ThermalSnapshot
+}
+
+func (f fakeThermal) Snapshot() sensors.ThermalSnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMapsThermalAndFallsBackForCPUTemp(t *testing.T) {
+	thermal := fakeThermal{snapshot: sensors.ThermalSnapshot{
+		Zones: []sensors.ThermalZone{
+			{Name: "thermal_zone0", Type: "cpu-thermal", TempC: 52.6, Trips: []sensors.TripPoint{{Type: "passive", TempC: 80}}},
+		},
+		Cooling: []sensors.CoolingDevice{{Name: "cooling_device0", Type: "pwm-fan", CurState: 2, MaxState: 4}},
+	}}
+	cpuTemp := func(lm fakeLmSensors) float64 {
+		s := newWithSources(&server.Server{}, time.Second, cpuSource(cpuReaders{temps: lm, thermal: thermal})).buildSnapshot()
+		return section[CPU](t, s, "cpu").TempC
+	}
+
+	if temp := cpuTemp(fakeLmSensors{}); temp != 52.6 {
+		t.Fatalf("CPU temp should fall back to the thermal zone, got %v", temp)
+	}
+	if temp := cpuTemp(fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{CPUTempC: 70.1, HasCPUTemp: true}}); temp != 70.1 {
+		t.Fatalf("hwmon CPU temp should win over the thermal zone, got %v", temp)
+	}
+	// A hwmon sensor reading 0 is still a reading.
+	if temp := cpuTemp(fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{HasCPUTemp: true}}); temp != 0 {
+		t.Fatalf("hwmon CPU temp of 0 should win over the thermal zone, got %v", temp)
+	}
+
+	zones := section[Thermal](t, newWithSources(&server.Server{}, time.Second, thermalSource(thermal)).buildSnapshot(), "thermal")
+	if len(zones.Zones) != 1 || zones.Zones[0].Type != "cpu-thermal" || len(zones.Zones[0].Trips) != 1 || zones.Zones[0].Trips[0] != (TripPoint{Type: "passive", TempC: 80}) {
+		t.Fatalf("zones mismatch: got %+v", zones.Zones)
+	}
+	if want := (CoolingDevice{Name: "cooling_device0", Type: "pwm-fan", CurState: 2, MaxState: 4}); len(zones.Cooling) != 1 || zones.Cooling[0] != want {
+		t.Fatalf("cooling mismatch: got %+v, want [%+v]", zones.Cooling, want)
+	}
+}
+
+type fakeFilesystems struct {
+	noopSampler
+	filesystems []sensors.Filesystem
+}
+
+func (f fakeFilesystems) Snapshot() sensors.FilesystemSnapshot {
+	return sensors.FilesystemSnapshot{Filesystems: f.filesystems}
+}
+
+func TestBuildSnapshotMapsFilesystems(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, filesystemSource(fakeFilesystems{filesystems: []sensors.Filesystem{
+		{MountPoint: "/mnt/games", Device: "/dev/nvme1n1p1", FSType: "btrfs", TotalGiB: 1863, UsedGiB: 1771.2, FreeGiB: 90.9, UsedPct: 95.1},
+	}}))
+
+	s := m.buildSnapshot()
+	filesystems := section[[]Filesystem](t, s, "filesystems")
+
+	want := Filesystem{MountPoint: "/mnt/games", Device: "/dev/nvme1n1p1", FSType: "btrfs", TotalGiB: 1863, UsedGiB: 1771.2, FreeGiB: 90.9, UsedPct: 95.1}
+	if len(filesystems) != 1 || filesystems[0] != want {
+		t.Fatalf("filesystems mismatch: got %+v, want [%+v]", filesystems, want)
+	}
+	empty := newWithSources(&server.Server{}, time.Second, filesystemSource(fakeFilesystems{})).buildSnapshot()
+	if fs := section[[]Filesystem](t, empty, "filesystems"); fs == nil || len(fs) != 0 {
+		t.Fatalf("filesystems without mounts should be empty, got %#v", fs)
+	}
+}
+
+func TestBuildFilesystemsReadsSamplerOnly(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second)
+	if fs := m.buildFilesystems(); fs == nil || len(fs) != 0 {
+		t.Fatalf("filesystems without a sampler should be empty, got %#v", fs)
+	}
+
+	m.filesystems = fakeFilesystems{filesystems: []sensors.Filesystem{{MountPoint: "/", FSType: "ext4", TotalGiB: 931.5}}}
+	if fs := m.buildFilesystems(); len(fs) != 1 || fs[0].MountPoint != "/" || fs[0].TotalGiB != 931.5 {
+		t.Fatalf("buildFilesystems got %+v", fs)
+	}
+}
+
+type fakeCPUThrottle struct {
+	noopSampler
+	snapshot sensors.CPUThrottleSnapshot
+}
+
+func (f fakeCPUThrottle) Snapshot() sensors.CPUThrottleSnapshot {
+	return f.snapshot
+}
+
+func TestBuildSnapshotMapsCPUThrottle(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, cpuSource(cpuReaders{
+		throttle: fakeCPUThrottle{snapshot: sensors.CPUThrottleSnapshot{
+			Throttling:   true,
+			Source:       sensors.CPUThrottleSourceThermal,
+			Events:       2,
+			PackageCount: 41,
+			Cores:        []sensors.CPUCoreThrottle{{ID: 0, Count: 12, Throttling: true}, {ID: 1, Count: 3}},
+		}},
+	}))
+
+	s := m.buildSnapshot()
+	cpu := section[CPU](t, s, "cpu")
+
+	if !cpu.Throttling || cpu.Throttle.Source != "thermal_throttle" || cpu.Throttle.Events != 2 || cpu.Throttle.PackageCount != 41 {
+		t.Fatalf("CPU throttle mismatch: got throttling=%v %+v", cpu.Throttling, cpu.Throttle)
+	}
+	want := []CPUCoreThrottle{{ID: 0, Count: 12, Throttling: true}, {ID: 1, Count: 3}}
+	if len(cpu.Throttle.Cores) != 2 || cpu.Throttle.Cores[0] != want[0] || cpu.Throttle.Cores[1] != want[1] {
+		t.Fatalf("CPU throttle cores got %+v, want %+v", cpu.Throttle.Cores, want)
+	}
+}
+
+func TestBuildSnapshotMapsVoltages(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, hwmonSource(fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{Voltages: []sensors.Voltage{
+		{Chip: "nct6798", Channel: "in0", Label: "Vcore", Volts: 1.104},
+		{Chip: "amdgpu", PCIAddr: "0000:03:00.0", Channel: "in0", Label: "vddgfx", Volts: 0.825},
+	}}}, nil))
+
+	s := m.buildSnapshot()
+	voltages := section[[]Voltage](t, s, "voltages")
+
+	if len(voltages) != 2 {
+		t.Fatalf("expected 2 voltages, got %+v", voltages)
+	}
+	want := Voltage{Chip: "nct6798", Channel: "in0", Label: "Vcore", Volts: 1.104}
+	if voltages[0] != want {
+		t.Fatalf("voltage mismatch: got %+v, want %+v", voltages[0], want)
+	}
+	if voltages[1].PCIAddr != "0000:03:00.0" || voltages[1].Label != "vddgfx" {
+		t.Fatalf("GPU voltage mismatch: got %+v", voltages[1])
+	}
+}
+
+type fakeCgroups struct {
+	noopSampler
+	snapshot sensors.CgroupSnapshot
+}
+
+func (f fakeCgroups) Snapshot() sensors.CgroupSnapshot {
+	return f.snapshot
+}
+
+func TestBuildCgroups(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second, cpuSource(cpuReaders{busy: fakeCPUBusy{}}))
+	m.cgroups = fakeCgroups{snapshot: sensors.CgroupSnapshot{Groups: []sensors.Cgroup{
+		{Path: "system.slice/docker-llm.scope", CPUPct: 1480.5, MemoryMiB: 24576, MemoryMaxMiB: 32768, MemoryPct: 75, ReadBytesPerSec: 512e6, WriteBytesPerSec: 4096, ReadOpsPerSec: 4000, WriteOpsPerSec: 2},
+	}}}
+
+	c := m.buildCgroups()
+
+	want := Cgroup{Path: "system.slice/docker-llm.scope", CPUPct: 1480.5, MemoryMiB: 24576, MemoryMaxMiB: 32768, MemoryPct: 75, ReadBytesPerS: 512e6, WriteBytesPerS: 4096, ReadIOPS: 4000, WriteIOPS: 2}
+	if len(c.Groups) != 1 || c.Groups[0] != want {
+		t.Fatalf("cgroups mismatch: got %+v, want %+v", c.Groups, want)
+	}
+
+	if frame := m.buildFrame(parseTopics("processes")); frame[TopicCgroups] != nil {
+		t.Fatalf("cgroups should be omitted without the topic, got %+v", frame[TopicCgroups])
+	}
+	if c := section[Cgroups](t, m.buildFrame(parseTopics("cgroups")), TopicCgroups); len(c.Groups) != 1 {
+		t.Fatalf("cgroups topic got %+v", c)
+	}
+}
+
+func TestBuildCgroupsEmpty(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second)
+
+	if c := m.buildCgroups(); c.Groups == nil || len(c.Groups) != 0 {
+		t.Fatalf("empty cgroups should be a non-nil list, got %#v", c.Groups)
+	}
+}
+
+type fakeSampler struct {
+	fakeCPUBusy
+	started context.Context
+	stopped bool
+}
+
+func (f *fakeSampler) Start(ctx context.Context) { f.started = ctx }
+func (f *fakeSampler) Stop()                     { f.stopped = true }
+
+func TestStartStopSamplers(t *testing.T) {
+	cpu := &fakeSampler{}
+	m := newWithSources(&server.Server{}, time.Second,
+		cpuSource(cpuReaders{busy: cpu, power: fakeCPUPower{}}),
+		memorySource(fakeRAM{}),
+	)
+
+	ctx := context.Background()
+	m.Start(ctx)
+	if cpu.started != ctx {
+		t.Fatal("Start should start samplers with the given context")
+	}
+
+	m.Stop()
+	if !cpu.stopped {
+		t.Fatal("Stop should stop samplers")
+	}
+}
+
+func TestBuildSensorsFromProviders(t *testing.T) {
+	lm := fakeLmSensors{snapshot: sensors.LmSensorsSnapshot{
+		GPUs:     []sensors.GPUSensors{{PCIAddr: "0000:03:00.0", Readings: sensors.GPUEdgeTemp | sensors.GPUFan, EdgeC: 61}},
+		Voltages: []sensors.Voltage{{Chip: "nct6798", Channel: "in0", Label: "Vcore", Volts: 1.104}},
+	}}
+	m := newWithSources(&server.Server{}, time.Second,
+		cpuSource(cpuReaders{
+			busy:  fakeCPUBusy{util: 42, cores: []sensors.CPUCoreBusy{{ID: 0, UtilPct: 80}}},
+			power: fakeCPUPower{},
+		}),
+		memorySource(fakeRAM{snapshot: sensors.SystemRAMSnapshot{TotalGiB: 32, UsedGiB: 8, AvailGiB: 24, UsedPct: 25}}),
+		hwmonSource(lm, nil),
+		gpuSource(gpuReaders{hwmon: lm}, ""),
+		thermalSource(fakeThermal{snapshot: sensors.ThermalSnapshot{Zones: []sensors.ThermalZone{
+			{Name: "thermal_zone0", Type: "x86_pkg_temp", TempC: 55},
+		}}}),
+	)
+
+	s := m.buildSensors("")
+
+	byID := make(map[string]Metric)
+	for _, metric := range s.Metrics {
+		byID[metric.ID] = metric
+	}
+	if got := byID["cpu.util_pct"]; got.Value != 42 || got.Unit != UnitPercent || got.Source != "cpu" {
+		t.Fatalf("cpu.util_pct got %+v", got)
+	}
+	if got := byID["cpu.core.0.util_pct"]; got.Value != 80 {
+		t.Fatalf("cpu.core.0.util_pct got %+v", got)
+	}
+	if got := byID["ram.used_gib"]; got.Value != 8 || got.Unit != UnitGiB {
+		t.Fatalf("ram.used_gib got %+v", got)
+	}
+	if got := byID["gpu.0.edge_c"]; got.Value != 61 || got.Meta["pci_addr"] != "0000:03:00.0" {
+		t.Fatalf("gpu.0.edge_c got %+v", got)
+	}
+	if got := byID["voltage.nct6798.in0.volts"]; got.Value != 1.104 || got.Label != "Vcore" || got.Unit != UnitVolts {
+		t.Fatalf("voltage got %+v", got)
+	}
+	// No hwmon CPU temperature, so the thermal zone fills cpu.temp_c.
+	if got := byID["cpu.temp_c"]; got.Value != 55 || got.Source != "thermal" {
+		t.Fatalf("cpu.temp_c fallback got %+v", got)
+	}
+	if _, ok := byID["cpu.power_w"]; ok {
+		t.Fatal("cpu.power_w should be missing without RAPL domains")
+	}
+	if _, ok := byID["gpu.0.power_w"]; ok {
+		t.Fatal("gpu.0.power_w should be missing when the driver reports none")
+	}
+	// A stopped fan reads 0 RPM but the sensor is there.
+	if got, ok := byID["gpu.0.fan_rpm"]; !ok || got.Value != 0 {
+		t.Fatalf("gpu.0.fan_rpm got %+v, present %v", got, ok)
+	}
+
+	if got := m.buildSensors("gpu."); len(got.Metrics) == 0 || got.Metrics[0].ID[:4] != "gpu." {
+		t.Fatalf("prefix filter got %+v", got.Metrics)
+	}
+	if got := section[Sensors](t, m.buildFrame(parseTopics("sensors")), TopicSensors); len(got.Metrics) != len(s.Metrics) {
+		t.Fatalf("sensors topic got %+v", got)
+	}
+}
+
+func TestBuildSensorsSkipsMissingSamplers(t *testing.T) {
+	m := newWithSources(&server.Server{}, time.Second)
+
+	s := m.buildSensors("")
+	if len(s.Providers) != 0 || s.Metrics == nil || len(s.Metrics) != 0 {
+		t.Fatalf("expected no providers and an empty list, got %+v", s)
+	}
+}
+
+func TestBuildSnapshotWithoutSources(t *testing.T) {
+	if s := newWithSources(&server.Server{}, time.Second).buildSnapshot(); s == nil || len(s) != 0 {
+		t.Fatalf("snapshot without sources should be an empty object, got %#v", s)
+	}
+
+	// Sources with nothing to report still encode their lists as [].
+	s := newWithSources(&server.Server{}, time.Second,
+		cpuSource(cpuReaders{}),
+		hwmonSource(nil, nil),
+		gpuSource(gpuReaders{}, ""),
+		thermalSource(fakeThermal{}),
+		storageSource(fakeStorage{}),
+	).buildSnapshot()
+	if cpu := section[CPU](t, s, "cpu"); cpu.Cores == nil || cpu.Freq.Cores == nil || cpu.PowerDomains == nil || cpu.Throttle.Cores == nil {
+		t.Fatalf("cpu lists should be empty, not nil: got %+v", cpu)
+	}
+	if gpus, fans, drives := section[[]GPU](t, s, "gpus"), section[[]Fan](t, s, "fans"), section[[]Drive](t, s, "drives"); gpus == nil || fans == nil || drives == nil {
+		t.Fatalf("lists should be empty, not nil: got %+v", s)
+	}
+	if thermal := section[Thermal](t, s, "thermal"); thermal.Zones == nil || thermal.Cooling == nil {
+		t.Fatalf("thermal lists should be empty, not nil: got %+v", thermal)
+	}
+}
+
+func TestWithSourceRegistersExtraSource(t *testing.T) {
+	ups := &fakeSampler{}
+	src := NewSource("ups", []sensors.Sampler{ups}, func() []Metric {
+		return []Metric{{ID: "ups.load_pct", Unit: UnitPercent, Value: 31}}
+	}, func() map[string]any {
+		return map[string]any{"ups": map[string]float64{"load_pct": 31}}
+	})
+	svc := &Service{}
+	WithSource(src)(svc)
+	m := newWithSources(&server.Server{}, time.Second, svc.sources...)
 
-	if s.RAM.TotalGB != 0 || s.RAM.UsedGB != 0 || s.RAM.AvailGB != 0 || s.RAM.UsedPct != 0 {
-		t.Fatalf("expected zero RAM when sampler errors, got %+v", s.RAM)
+	m.Start(context.Background())
+	m.Stop()
+	if ups.started == nil || !ups.stopped {
+		t.Fatalf("source sampler should be started and stopped, got %+v", ups)
 	}
-	if s.CPU.TempC != 50 || s.CPU.PackageTempC != 48 || s.CPU.UtilPct != 10 || s.CPU.PowerW != 20 {
-		t.Fatalf("non-RAM fields should still map, got CPU=%+v", s.CPU)
+	if got := m.buildSensors("ups."); len(got.Metrics) != 1 || got.Metrics[0].Source != "ups" {
+		t.Fatalf("source metrics got %+v", got)
 	}
-	if s.GPU.UtilPct != 30 || s.GPU.PowerW != 100 || s.GPU.VramUsedPct != 50 {
-		t.Fatalf("non-RAM fields should still map, got GPU=%+v", s.GPU)
+	if got := section[map[string]float64](t, m.buildSnapshot(), "ups"); got["load_pct"] != 31 {
+		t.Fatalf("source section got %+v", got)
 	}
 }
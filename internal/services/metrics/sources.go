@@ -0,0 +1,655 @@
+package metrics
+
+import (
+	"strconv"
+
+	"sensorpanel/internal/lib/sensors"
+)
+
+// builtinSources constructs the samplers of every built-in sensor and wraps
+// them in sources, in registration order. Where two sources report the same
+// metric the first wins, so hwmon's cpu.temp_c is preferred over the
+// thermal zone fallback.
+func (m *Service) builtinSources() []Source {
+	interval, root := m.sampleInterval, m.root
+
+	// lm-sensors feeds the cpu, hwmon and gpu sources, and the thermal
+	// zones the cpu and thermal sources.
+	lm := sensors.NewLmSensorsSampler(interval, root)
+	thermal := sensors.NewThermalSampler(interval, root)
+
+	gpus := gpuReaders{hwmon: lm}
+	if m.gpuBackendEnabled(GPUBackendAMDGPU) {
+		gpus.busy = sensors.NewGPUBusySampler(interval, root)
+		gpus.vram = sensors.NewGPUVRAMSampler(interval, root)
+		gpus.amdgpu = sensors.NewAMDGPUMetricsSampler(interval, root)
+		gpus.clock = sensors.NewGPUClockSampler(interval, root)
+	}
+	if m.gpuBackendEnabled(GPUBackendNvidia) {
+		gpus.nvidia = sensors.NewNvidiaSMISampler(interval)
+	}
+	if m.gpuBackendEnabled(GPUBackendIntel) {
+		gpus.intel = sensors.NewIntelGPUSampler(interval, root)
+	}
+
+	m.filesystems = sensors.NewFilesystemSampler(interval, root, m.fsFilter)
+
+	sources := []Source{
+		cpuSource(cpuReaders{
+			busy:     sensors.NewCPUBusySampler(interval, root),
+			power:    sensors.NewCPUPowerSampler(interval, root),
+			freq:     sensors.NewCPUFreqSampler(interval, root),
+			throttle: sensors.NewCPUThrottleSampler(interval, root),
+			temps:    lm,
+			thermal:  thermal,
+		}),
+		memorySource(sensors.NewSystemRAMSampler(interval, root)),
+		hwmonSource(lm, sensors.NewFanSampler(interval, root)),
+		gpuSource(gpus, m.primaryGPU),
+		thermalSource(thermal),
+		storageSource(sensors.NewStorageSampler(interval, root, m.smartctl)),
+		diskSource(sensors.NewDiskIOSampler(interval, root, m.allDisks)),
+		netSource(sensors.NewNetIOSampler(interval, root, m.allNetIfaces)),
+		batterySource(sensors.NewBatterySampler(interval, root)),
+		pressureSource(sensors.NewPressureSampler(interval, root)),
+		filesystemSource(m.filesystems),
+	}
+
+	// Cgroups and processes are served on their own endpoints, so their
+	// sources only run the sampler. Without process sampling the process
+	// sampler is not registered; buildProcesses starts it on the first
+	// /api/processes request instead.
+	m.cgroups = sensors.NewCgroupSampler(interval, root, m.cgroupPaths)
+	sources = append(sources, NewSource("cgroups", []sensors.Sampler{m.cgroups}, nil, nil))
+	m.processes = sensors.NewProcessSampler(interval, root, m.processTopN)
+	if m.processSample {
+		sources = append(sources, NewSource("processes", []sensors.Sampler{m.processes}, nil, nil))
+	}
+
+	return sources
+}
+
+// metricSet accumulates one source's readings.
+type metricSet []Metric
+
+func (s *metricSet) add(id string, label string, unit string, value float64, meta map[string]string) {
+	*s = append(*s, Metric{ID: id, Label: label, Unit: unit, Value: value, Meta: meta})
+}
+
+// cpuReaders are the CPU samplers; any of them may be nil. temps and
+// thermal are shared with the hwmon and thermal sources and only give the
+// cpu section its temperatures.
+type cpuReaders struct {
+	busy     reader[sensors.CPUBusySnapshot]
+	power    reader[sensors.CPUPowerSnapshot]
+	freq     reader[sensors.CPUFreqSnapshot]
+	throttle reader[sensors.CPUThrottleSnapshot]
+	temps    reader[sensors.LmSensorsSnapshot]
+	thermal  reader[sensors.ThermalSnapshot]
+}
+
+func cpuSource(r cpuReaders) Source {
+	metrics := func() []Metric {
+		var set metricSet
+		if r.busy != nil {
+			busy := r.busy.Snapshot()
+			set.add("cpu.util_pct", "CPU utilization", UnitPercent, busy.UtilPct, nil)
+			for _, core := range busy.Cores {
+				id := strconv.Itoa(core.ID)
+				set.add(metricID("cpu", "core", id, "util_pct"), "Core "+id+" utilization", UnitPercent, core.UtilPct, nil)
+			}
+		}
+
+		if r.power != nil {
+			power := r.power.Snapshot()
+			if len(power.Domains) > 0 {
+				set.add("cpu.power_w", "CPU package power", UnitWatts, power.PowerW, nil)
+			}
+			for _, domain := range power.Domains {
+				set.add(metricID("cpu", "power", domain.Zone, "power_w"), domain.Name, UnitWatts, domain.PowerW,
+					map[string]string{"zone": domain.Zone})
+			}
+		}
+
+		if r.freq != nil {
+			freq := r.freq.Snapshot()
+			if len(freq.Cores) > 0 {
+				set.add("cpu.freq.avg_mhz", "CPU average clock", UnitMHz, freq.AvgMHz, nil)
+				set.add("cpu.freq.max_mhz", "CPU fastest core clock", UnitMHz, freq.MaxMHz, nil)
+			}
+			for _, core := range freq.Cores {
+				id := strconv.Itoa(core.ID)
+				set.add(metricID("cpu", "core", id, "freq_mhz"), "Core "+id+" clock", UnitMHz, core.CurMHz, nil)
+			}
+		}
+
+		return set
+	}
+
+	sections := func() map[string]any {
+		cpu := CPU{
+			Cores:        []CPUCore{},
+			Freq:         CPUFreq{Cores: []CPUCoreFreq{}},
+			PowerDomains: []CPUPowerDomain{},
+			Throttle:     CPUThrottle{Cores: []CPUCoreThrottle{}},
+		}
+
+		// ARM SBCs and some laptops have no hwmon CPU chip; fall back to
+		// the CPU thermal zone. A hwmon sensor reading 0 still wins.
+		tempSet := false
+		if r.temps != nil {
+			temps := r.temps.Snapshot()
+			cpu.TempC = temps.CPUTempC
+			cpu.PackageTempC = temps.CPUPackageTempC
+			tempSet = temps.HasCPUTemp
+		}
+		if r.thermal != nil && !tempSet {
+			if temp, ok := r.thermal.Snapshot().CPUTempC(); ok {
+				cpu.TempC = temp
+			}
+		}
+
+		if r.busy != nil {
+			busy := r.busy.Snapshot()
+			cpu.UtilPct = busy.UtilPct
+			cpu.CPUTimes = cpuTimes(busy.CPUTimesPct)
+			for _, core := range busy.Cores {
+				cpu.Cores = append(cpu.Cores, CPUCore{
+					ID:       core.ID,
+					UtilPct:  core.UtilPct,
+					CPUTimes: cpuTimes(core.CPUTimesPct),
+				})
+			}
+		}
+
+		if r.power != nil {
+			power := r.power.Snapshot()
+			cpu.PowerW = power.PowerW
+			for _, domain := range power.Domains {
+				cpu.PowerDomains = append(cpu.PowerDomains, CPUPowerDomain{
+					Zone:   domain.Zone,
+					Name:   domain.Name,
+					PowerW: domain.PowerW,
+				})
+			}
+		}
+
+		if r.freq != nil {
+			cpu.Freq = cpuFreq(r.freq.Snapshot())
+		}
+
+		if r.throttle != nil {
+			throttle := r.throttle.Snapshot()
+			cpu.Throttling = throttle.Throttling
+			cpu.Throttle.Source = throttle.Source
+			cpu.Throttle.Events = throttle.Events
+			cpu.Throttle.PackageCount = throttle.PackageCount
+			for _, core := range throttle.Cores {
+				cpu.Throttle.Cores = append(cpu.Throttle.Cores, CPUCoreThrottle{
+					ID:         core.ID,
+					Count:      core.Count,
+					Throttling: core.Throttling,
+				})
+			}
+		}
+
+		return map[string]any{"cpu": cpu}
+	}
+
+	return NewSource("cpu", []sensors.Sampler{r.busy, r.power, r.freq, r.throttle, r.temps, r.thermal}, metrics, sections)
+}
+
+func memorySource(ram fallibleReader[sensors.SystemRAMSnapshot]) Source {
+	metrics := func() []Metric {
+		snapshot, err := ram.Snapshot()
+		if err != nil {
+			return nil
+		}
+
+		var set metricSet
+		set.add("ram.total_gib", "RAM total", UnitGiB, snapshot.TotalGiB, nil)
+		set.add("ram.used_gib", "RAM used", UnitGiB, snapshot.UsedGiB, nil)
+		set.add("ram.avail_gib", "RAM available", UnitGiB, snapshot.AvailGiB, nil)
+		set.add("ram.used_pct", "RAM used", UnitPercent, snapshot.UsedPct, nil)
+		if snapshot.SwapTotalGiB > 0 {
+			set.add("ram.swap.used_gib", "Swap used", UnitGiB, snapshot.SwapUsedGiB, nil)
+			set.add("ram.swap.used_pct", "Swap used", UnitPercent, snapshot.SwapUsedPct, nil)
+		}
+
+		return set
+	}
+
+	// A failed read leaves the ram section zero.
+	sections := func() map[string]any {
+		var resp RAM
+		mem, err := ram.Snapshot()
+		if err != nil {
+			return map[string]any{"ram": resp}
+		}
+
+		resp.TotalGiB = mem.TotalGiB
+		resp.UsedGiB = mem.UsedGiB
+		resp.AvailGiB = mem.AvailGiB
+		resp.UsedPct = mem.UsedPct
+		resp.FreeGiB = mem.FreeGiB
+		resp.BuffersGiB = mem.BuffersGiB
+		resp.CachedGiB = mem.CachedGiB
+		resp.ShmemGiB = mem.ShmemGiB
+		resp.DirtyMiB = mem.DirtyMiB
+		resp.WritebackMiB = mem.WritebackMiB
+
+		resp.Swap.TotalGiB = mem.SwapTotalGiB
+		resp.Swap.UsedGiB = mem.SwapUsedGiB
+		resp.Swap.UsedPct = mem.SwapUsedPct
+
+		resp.Zram.Devices = mem.Zram.Devices
+		resp.Zram.OrigGiB = mem.Zram.OrigGiB
+		resp.Zram.ComprGiB = mem.Zram.ComprGiB
+		resp.Zram.MemUsedGiB = mem.Zram.MemUsedGiB
+		resp.Zram.Ratio = mem.Zram.Ratio
+
+		resp.HugePages.Total = mem.HugePagesTotal
+		resp.HugePages.Free = mem.HugePagesFree
+		resp.HugePages.SizeKiB = mem.HugePageSizeKiB
+		resp.HugePages.UsedGiB = mem.HugePagesUsedGiB
+
+		return map[string]any{"ram": resp}
+	}
+
+	return NewSource("memory", []sensors.Sampler{ram}, metrics, sections)
+}
+
+// hwmonSource reports the CPU temperatures, fans and voltage rails read
+// from hwmon chips. Either reader may be nil. The cpu section takes its
+// temperatures from cpuSource.
+func hwmonSource(lm reader[sensors.LmSensorsSnapshot], fans reader[sensors.FanSnapshot]) Source {
+	metrics := func() []Metric {
+		var set metricSet
+		if lm != nil {
+			snapshot := lm.Snapshot()
+			if snapshot.HasCPUTemp {
+				set.add("cpu.temp_c", "CPU temperature", UnitCelsius, snapshot.CPUTempC, nil)
+				set.add("cpu.package_temp_c", "CPU package temperature", UnitCelsius, snapshot.CPUPackageTempC, nil)
+			}
+			for _, voltage := range snapshot.Voltages {
+				set.add(metricID("voltage", chipKey(voltage.Chip, voltage.PCIAddr), voltage.Channel, "volts"), voltage.Label, UnitVolts, voltage.Volts,
+					chipMeta(voltage.Chip, voltage.PCIAddr))
+			}
+		}
+
+		if fans != nil {
+			for _, fan := range fans.Snapshot().Fans {
+				key := chipKey(fan.Chip, fan.PCIAddr)
+				set.add(metricID("fan", key, fan.Channel, "rpm"), fan.Label, UnitRPM, fan.RPM, chipMeta(fan.Chip, fan.PCIAddr))
+				if fan.HasPWM {
+					set.add(metricID("fan", key, fan.Channel, "pwm_pct"), fan.Label+" duty", UnitPercent, fan.PWMPct, chipMeta(fan.Chip, fan.PCIAddr))
+				}
+			}
+		}
+
+		return set
+	}
+
+	sections := func() map[string]any {
+		fanList, voltages := []Fan{}, []Voltage{}
+		if lm != nil {
+			for _, voltage := range lm.Snapshot().Voltages {
+				voltages = append(voltages, Voltage{
+					Chip:    voltage.Chip,
+					PCIAddr: voltage.PCIAddr,
+					Channel: voltage.Channel,
+					Label:   voltage.Label,
+					Volts:   voltage.Volts,
+				})
+			}
+		}
+
+		if fans != nil {
+			for _, fan := range fans.Snapshot().Fans {
+				fanList = append(fanList, Fan{
+					Chip:    fan.Chip,
+					PCIAddr: fan.PCIAddr,
+					Channel: fan.Channel,
+					Label:   fan.Label,
+					RPM:     fan.RPM,
+					PWMPct:  fan.PWMPct,
+				})
+			}
+		}
+
+		return map[string]any{"fans": fanList, "voltages": voltages}
+	}
+
+	return NewSource("hwmon", []sensors.Sampler{lm, fans}, metrics, sections)
+}
+
+// gpuSource merges every GPU backend by PCI address. Metric IDs number
+// GPUs in PCI address order, as in /metrics gpus. primary picks the flat
+// gpu section, see WithPrimaryGPU.
+func gpuSource(r gpuReaders, primary string) Source {
+	metrics := func() []Metric {
+		var set metricSet
+		for i, gpu := range r.gpus() {
+			idx := strconv.Itoa(i)
+			meta := map[string]string{"pci_addr": gpu.PCIAddr}
+			if gpu.Card != "" {
+				meta["card"] = gpu.Card
+			}
+			if gpu.Name != "" {
+				meta["name"] = gpu.Name
+			}
+
+			for _, m := range []struct {
+				reading sensors.GPUReadings
+				name    string
+				label   string
+				unit    string
+				value   float64
+			}{
+				{sensors.GPUUtil, "util_pct", "utilization", UnitPercent, gpu.UtilPct},
+				{sensors.GPUEdgeTemp, "edge_c", "edge temperature", UnitCelsius, gpu.EdgeC},
+				{sensors.GPUHotspotTemp, "hotspot_c", "hotspot temperature", UnitCelsius, gpu.HotspotC},
+				{sensors.GPUMemTemp, "vram_c", "memory temperature", UnitCelsius, gpu.VramC},
+				{sensors.GPUPower, "power_w", "power", UnitWatts, gpu.PowerW},
+				{sensors.GPUGraphicsClock, "gfx_clock_mhz", "graphics clock", UnitMHz, gpu.GraphicsClockMHz},
+				{sensors.GPUMemClock, "mem_clock_mhz", "memory clock", UnitMHz, gpu.MemClockMHz},
+				{sensors.GPUFan, "fan_rpm", "fan", UnitRPM, gpu.FanRPM},
+			} {
+				if gpu.readings.Has(m.reading) {
+					set.add(metricID("gpu", idx, m.name), "GPU "+idx+" "+m.label, m.unit, m.value, meta)
+				}
+			}
+			if gpu.VramTotalGB > 0 {
+				set.add(metricID("gpu", idx, "vram_used_gib"), "GPU "+idx+" VRAM used", UnitGiB, gpu.VramUsedGB, meta)
+				set.add(metricID("gpu", idx, "vram_used_pct"), "GPU "+idx+" VRAM used", UnitPercent, gpu.VramUsedPct, meta)
+			}
+		}
+
+		return set
+	}
+
+	// gpu is the primary GPU, kept flat for existing consumers; gpus lists
+	// every card.
+	sections := func() map[string]any {
+		gpus := r.gpus()
+		return map[string]any{"gpu": primaryGPU(gpus, primary), "gpus": gpus}
+	}
+
+	return NewSource("gpu", r.samplers(), metrics, sections)
+}
+
+// thermalSource is registered after hwmonSource, so its cpu.temp_c only
+// shows when no hwmon CPU chip reported one.
+func thermalSource(thermal reader[sensors.ThermalSnapshot]) Source {
+	metrics := func() []Metric {
+		snapshot := thermal.Snapshot()
+
+		var set metricSet
+		if temp, ok := snapshot.CPUTempC(); ok {
+			set.add("cpu.temp_c", "CPU temperature", UnitCelsius, temp, nil)
+		}
+		for _, zone := range snapshot.Zones {
+			set.add(metricID("thermal", zone.Name, "temp_c"), zone.Type, UnitCelsius, zone.TempC, map[string]string{"type": zone.Type})
+		}
+
+		return set
+	}
+
+	sections := func() map[string]any {
+		snapshot := thermal.Snapshot()
+		resp := Thermal{Zones: []ThermalZone{}, Cooling: []CoolingDevice{}}
+		for _, zone := range snapshot.Zones {
+			trips := make([]TripPoint, 0, len(zone.Trips))
+			for _, trip := range zone.Trips {
+				trips = append(trips, TripPoint{Type: trip.Type, TempC: trip.TempC})
+			}
+			resp.Zones = append(resp.Zones, ThermalZone{
+				Name:  zone.Name,
+				Type:  zone.Type,
+				TempC: zone.TempC,
+				Trips: trips,
+			})
+		}
+		for _, device := range snapshot.Cooling {
+			resp.Cooling = append(resp.Cooling, CoolingDevice{
+				Name:     device.Name,
+				Type:     device.Type,
+				CurState: device.CurState,
+				MaxState: device.MaxState,
+			})
+		}
+
+		return map[string]any{"thermal": resp}
+	}
+
+	return NewSource("thermal", []sensors.Sampler{thermal}, metrics, sections)
+}
+
+func storageSource(storage reader[sensors.StorageSnapshot]) Source {
+	metrics := func() []Metric {
+		var set metricSet
+		for _, drive := range storage.Snapshot().Drives {
+			if drive.HasTemp {
+				set.add(metricID("drive", drive.Name, "temp_c"), drive.Name+" temperature", UnitCelsius, drive.TempC,
+					map[string]string{"model": drive.Model, "kind": drive.Kind})
+			}
+		}
+
+		return set
+	}
+
+	sections := func() map[string]any {
+		drives := []Drive{}
+		for _, drive := range storage.Snapshot().Drives {
+			drives = append(drives, Drive{
+				Name:                       drive.Name,
+				Kind:                       drive.Kind,
+				Model:                      drive.Model,
+				TempC:                      drive.TempC,
+				TempWarnC:                  drive.TempWarnC,
+				TempCritC:                  drive.TempCritC,
+				Throttling:                 drive.Throttling,
+				SMART:                      drive.SMART,
+				HealthPassed:               drive.HealthPassed,
+				PercentageUsed:             drive.PercentageUsed,
+				AvailableSparePct:          drive.AvailableSparePct,
+				AvailableSpareThresholdPct: drive.AvailableSpareThresholdPct,
+				MediaErrors:                drive.MediaErrors,
+				CriticalWarning:            drive.CriticalWarning,
+				WarningTempMinutes:         drive.WarningTempMinutes,
+			})
+		}
+
+		return map[string]any{"drives": drives}
+	}
+
+	return NewSource("storage", []sensors.Sampler{storage}, metrics, sections)
+}
+
+func diskSource(diskIO reader[sensors.DiskIOSnapshot]) Source {
+	metrics := func() []Metric {
+		var set metricSet
+		for _, disk := range diskIO.Snapshot().Disks {
+			set.add(metricID("disk", disk.Name, "read_mb_s"), disk.Name+" read", UnitMBPerSec, disk.ReadMBps, nil)
+			set.add(metricID("disk", disk.Name, "write_mb_s"), disk.Name+" write", UnitMBPerSec, disk.WriteMBps, nil)
+			set.add(metricID("disk", disk.Name, "util_pct"), disk.Name+" utilization", UnitPercent, disk.UtilPct, nil)
+		}
+
+		return set
+	}
+
+	sections := func() map[string]any {
+		disks := []DiskIO{}
+		for _, disk := range diskIO.Snapshot().Disks {
+			disks = append(disks, DiskIO{
+				Name:         disk.Name,
+				ReadMBps:     disk.ReadMBps,
+				WriteMBps:    disk.WriteMBps,
+				ReadIOPS:     disk.ReadIOPS,
+				WriteIOPS:    disk.WriteIOPS,
+				UtilPct:      disk.UtilPct,
+				ReadAwaitMs:  disk.ReadAwaitMs,
+				WriteAwaitMs: disk.WriteAwaitMs,
+			})
+		}
+
+		return map[string]any{"disks": disks}
+	}
+
+	return NewSource("disk", []sensors.Sampler{diskIO}, metrics, sections)
+}
+
+func netSource(netIO reader[sensors.NetIOSnapshot]) Source {
+	metrics := func() []Metric {
+		var set metricSet
+		for _, iface := range netIO.Snapshot().Interfaces {
+			set.add(metricID("net", iface.Name, "rx_bytes_s"), iface.Name+" received", UnitBytesPerSec, iface.RxBytesPerSec, nil)
+			set.add(metricID("net", iface.Name, "tx_bytes_s"), iface.Name+" sent", UnitBytesPerSec, iface.TxBytesPerSec, nil)
+		}
+
+		return set
+	}
+
+	sections := func() map[string]any {
+		ifaces := []NetIO{}
+		for _, iface := range netIO.Snapshot().Interfaces {
+			ifaces = append(ifaces, NetIO{
+				Name:            iface.Name,
+				State:           iface.State,
+				SpeedMbps:       iface.SpeedMbps,
+				RxBytesPerSec:   iface.RxBytesPerSec,
+				TxBytesPerSec:   iface.TxBytesPerSec,
+				RxPacketsPerSec: iface.RxPacketsPerSec,
+				TxPacketsPerSec: iface.TxPacketsPerSec,
+				RxErrorsPerSec:  iface.RxErrorsPerSec,
+				TxErrorsPerSec:  iface.TxErrorsPerSec,
+			})
+		}
+
+		return map[string]any{"net": ifaces}
+	}
+
+	return NewSource("net", []sensors.Sampler{netIO}, metrics, sections)
+}
+
+func batterySource(battery reader[sensors.BatterySnapshot]) Source {
+	metrics := func() []Metric {
+		var set metricSet
+		for _, b := range battery.Snapshot().Batteries {
+			meta := map[string]string{"status": b.Status}
+			set.add(metricID("battery", b.Name, "capacity_pct"), b.Name+" charge", UnitPercent, b.CapacityPct, meta)
+			set.add(metricID("battery", b.Name, "power_w"), b.Name+" power", UnitWatts, b.PowerW, meta)
+			if b.HasTimeEstimate {
+				set.add(metricID("battery", b.Name, "time_to_empty_min"), b.Name+" time to empty", UnitMinutes, b.TimeToEmptyMin, meta)
+				set.add(metricID("battery", b.Name, "time_to_full_min"), b.Name+" time to full", UnitMinutes, b.TimeToFullMin, meta)
+			}
+		}
+
+		return set
+	}
+
+	// The battery section is omitted on machines without a system battery.
+	sections := func() map[string]any {
+		snapshot := battery.Snapshot()
+		if len(snapshot.Batteries) == 0 {
+			return nil
+		}
+
+		resp := Battery{ACOnline: snapshot.ACOnline, Batteries: make([]BatteryInfo, 0, len(snapshot.Batteries))}
+		for _, b := range snapshot.Batteries {
+			resp.Batteries = append(resp.Batteries, BatteryInfo{
+				Name:           b.Name,
+				Status:         b.Status,
+				CapacityPct:    b.CapacityPct,
+				PowerW:         b.PowerW,
+				EnergyWh:       b.EnergyWh,
+				EnergyFullWh:   b.EnergyFullWh,
+				EnergyDesignWh: b.EnergyDesignWh,
+				HealthPct:      b.HealthPct,
+				TimeToEmptyMin: b.TimeToEmptyMin,
+				TimeToFullMin:  b.TimeToFullMin,
+			})
+		}
+
+		return map[string]any{"battery": resp}
+	}
+
+	return NewSource("battery", []sensors.Sampler{battery}, metrics, sections)
+}
+
+func pressureSource(psi reader[sensors.PressureSnapshot]) Source {
+	metrics := func() []Metric {
+		snapshot := psi.Snapshot()
+
+		var set metricSet
+		for _, resource := range []struct {
+			name     string
+			pressure sensors.Pressure
+		}{{"cpu", snapshot.CPU}, {"memory", snapshot.Memory}, {"io", snapshot.IO}} {
+			set.add(metricID("pressure", resource.name, "some_avg10"), resource.name+" pressure (some)", UnitPercent, resource.pressure.Some.Avg10, nil)
+			set.add(metricID("pressure", resource.name, "full_avg10"), resource.name+" pressure (full)", UnitPercent, resource.pressure.Full.Avg10, nil)
+		}
+
+		return set
+	}
+
+	sections := func() map[string]any {
+		snapshot := psi.Snapshot()
+		return map[string]any{"pressure": Pressures{
+			CPU:    pressure(snapshot.CPU),
+			Memory: pressure(snapshot.Memory),
+			IO:     pressure(snapshot.IO),
+		}}
+	}
+
+	return NewSource("pressure", []sensors.Sampler{psi}, metrics, sections)
+}
+
+// filesystemSource only fills /metrics; mount usage is not on
+// /api/sensors.
+func filesystemSource(filesystems reader[sensors.FilesystemSnapshot]) Source {
+	sections := func() map[string]any {
+		return map[string]any{"filesystems": filesystemList(filesystems.Snapshot())}
+	}
+
+	return NewSource("filesystems", []sensors.Sampler{filesystems}, nil, sections)
+}
+
+func filesystemList(snapshot sensors.FilesystemSnapshot) []Filesystem {
+	list := make([]Filesystem, 0, len(snapshot.Filesystems))
+	for _, fs := range snapshot.Filesystems {
+		list = append(list, Filesystem{
+			MountPoint:    fs.MountPoint,
+			Device:        fs.Device,
+			FSType:        fs.FSType,
+			TotalGiB:      fs.TotalGiB,
+			UsedGiB:       fs.UsedGiB,
+			FreeGiB:       fs.FreeGiB,
+			UsedPct:       fs.UsedPct,
+			InodesTotal:   fs.InodesTotal,
+			InodesUsed:    fs.InodesUsed,
+			InodesUsedPct: fs.InodesUsedPct,
+		})
+	}
+
+	return list
+}
+
+// chipKey names a hwmon chip in metric IDs; PCI chips such as amdgpu carry
+// their address so two cards do not collide.
+func chipKey(chip string, pciAddr string) string {
+	if pciAddr == "" {
+		return chip
+	}
+
+	return chip + "-" + pciAddr
+}
+
+func chipMeta(chip string, pciAddr string) map[string]string {
+	meta := map[string]string{"chip": chip}
+	if pciAddr != "" {
+		meta["pci_addr"] = pciAddr
+	}
+
+	return meta
+}
@@ -824,11 +824,11 @@ function updateUI(data) {
 	radial.style.setProperty("--value", gpuUtil)
 	document.getElementById("gpu_util_text").textContent = `${gpuUtil}%`
 
-	const ramTotal = Math.round(data.ram.total_gb * 10) / 10
-	const ramUsed = Math.round(data.ram.used_gb * 10) / 10
+	const ramTotal = Math.round(data.ram.total_gib * 10) / 10
+	const ramUsed = Math.round(data.ram.used_gib * 10) / 10
 	const ramUsedPct = Math.round(data.ram.used_pct * 10) / 10
 	document.getElementById("ram_progress").value = ramUsedPct
-	document.getElementById("ram_desc").textContent = `RAM ${ramUsed}/${ramTotal}gb (${ramUsedPct}%)`
+	document.getElementById("ram_desc").textContent = `RAM ${ramUsed}/${ramTotal}GiB (${ramUsedPct}%)`
 }
 
 function setConnectionState(state) {